@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var noSpawn bool
+
+func init() {
+	Command.Flags().BoolVarP(&noSpawn, "no-spawn", "n", noSpawn, "Do not start the daemon if it is not running")
+}
+
+// reconnectDelay is the delay before reconnecting to the daemon.
+const reconnectDelay = time.Second
+
+// Command is the client command.
+var Command = &cobra.Command{
+	Use: "client",
+	Example: `
+  # Use in waybar config instead of running waybar-lyric directly
+  "exec": "waybar-lyric client"
+  `,
+	Short: "Stream lyrics from the shared daemon",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		path := daemon.SocketPath()
+
+		for {
+			conn, err := daemon.Dial(ctx, path, !noSpawn)
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			slog.Debug("Connected to daemon", "socket", path)
+
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			_, err = io.Copy(cmd.OutOrStdout(), conn)
+			stop()
+			conn.Close()
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			slog.Warn("Lost connection to daemon", "error", err)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(reconnectDelay):
+			}
+		}
+	},
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Nadim147c/waybar-lyric/internal/daemon"
+	"github.com/Nadim147c/waybar-lyric/internal/waybar"
+	"github.com/spf13/cobra"
+)
+
+// daemonCommand runs the main loop once and shares its output with every
+// 'waybar-lyric client' over a unix socket.
+var daemonCommand = &cobra.Command{
+	Use: "daemon",
+	Example: `
+  # Start the daemon manually (client starts it automatically)
+  waybar-lyric daemon --max-length=80
+  `,
+	Short: "Run the lyrics daemon shared by clients",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		path := daemon.SocketPath()
+		ln, closer, err := daemon.Listen(path)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		slog.Info("Daemon listening", "socket", path)
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		hub := daemon.NewHub()
+		waybar.SetOutput(hub)
+
+		go func() {
+			if err := hub.Serve(ctx, ln); err != nil {
+				slog.Error("Daemon stopped accepting clients", "error", err)
+				cancel()
+			}
+		}()
+
+		return run(ctx)
+	},
+}
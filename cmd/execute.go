@@ -13,6 +13,10 @@ import (
 	"github.com/Nadim147c/waybar-lyric/internal/lyric"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
 	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/server"
+	"github.com/Nadim147c/waybar-lyric/internal/service"
+	"github.com/Nadim147c/waybar-lyric/internal/state"
+	"github.com/Nadim147c/waybar-lyric/internal/str"
 	"github.com/Nadim147c/waybar-lyric/internal/waybar"
 	"github.com/godbus/dbus/v5"
 	"github.com/spf13/cobra"
@@ -29,178 +33,303 @@ func Execute(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
+	return run(cmd.Context())
+}
+
+// run runs the main loop and encodes lyrics to waybar.Output until ctx is done.
+func run(ctx context.Context) error {
 	conn, err := dbus.SessionBus()
 	if err != nil {
 		slog.Error("Failed to create dbus connection", "error", err)
 		return nil
 	}
 
-	ctx, cancel := context.WithCancel(cmd.Context())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Main loop
-	ticker := time.NewTicker(config.UpdateInterval)
-	defer ticker.Stop()
+	// Main loop wakes up at next line or word change, see wakeInterval
+	timer := time.NewTimer(config.UpdateInterval)
+	defer timer.Stop()
 
 	var mprisPlayer *mpris.Player
 	for mprisPlayer == nil {
-		p, err := player.Select(conn)
+		p, err := player.SelectSlot(conn, config.PlayerSlot)
 		if err != nil {
 			slog.Debug("Failed to select player", "error", err)
 			time.Sleep(config.UpdateInterval)
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-ticker.C:
+			case <-timer.C:
+				timer.Reset(config.UpdateInterval)
 			}
 		}
 		mprisPlayer = p
 	}
 	slog.Debug("Player selected", "player", mprisPlayer)
 
-	signals := make(chan *dbus.Signal)
+	tracker := player.NewTracker(conn)
+	signals := make(chan *dbus.Signal, 16)
+	if err := tracker.Subscribe(signals); err != nil {
+		slog.Warn("Failed to subscribe player signals", "error", err)
+	}
+
+	reload, err := config.Watch(ctx)
+	if err != nil {
+		slog.Warn("Config hot-reload is disabled", "error", err)
+	}
+
+	var publishers []state.Publisher
+	if config.DBusService {
+		svc, err := service.Export(conn)
+		if err != nil {
+			slog.Error("Failed to export D-Bus service", "error", err)
+		} else {
+			slog.Info("D-Bus service exported", "name", service.BusName)
+			publishers = append(publishers, svc)
+		}
+	}
 
-	go mpris.OnSignal(conn, signals)
+	if config.ServeAddress != "" {
+		srv := server.New()
+		publishers = append(publishers, srv)
+		go func() {
+			if err := srv.ListenAndServe(ctx, config.ServeAddress); err != nil {
+				slog.Error("Failed to serve lyrics", "error", err)
+			}
+		}()
+	}
 
 	var lastWaybar *waybar.Waybar
 
+	// emit publishes the state and encodes the waybar if it has changed. It
+	// returns true if waybar is encoded.
+	emit := func(w *waybar.Waybar, s state.State) bool {
+		timer.Reset(wakeInterval(s))
+
+		s.Waybar = w
+		for _, p := range publishers {
+			p.Publish(s)
+		}
+		if w.Is(lastWaybar) {
+			return false
+		}
+		w.Encode()
+		lastWaybar = w
+		return true
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-signals:
-			slog.Debug("Received player update signal")
-		case <-ticker.C:
+		case sig := <-signals:
+			if !tracker.Handle(sig) {
+				continue
+			}
+		case <-reload:
+			changed, err := config.Reload()
+			if err != nil {
+				slog.Error("Failed to reload config", "error", err)
+				continue
+			}
+			slog.Info("Config reloaded", "changed", changed)
+			if err := waybar.CheckFormats(); err != nil {
+				slog.Error("Invalid format in config", "error", err)
+			}
+			if lyric.ProcessingChanged(changed) {
+				lyric.Store.Invalidate()
+			}
+			lastWaybar = nil // emit with new options immediately
+		case id := <-lyric.Upgraded:
+			// upgraded lyrics are processed by next Store.Load, and published
+			// again since they are not the same lyrics
+			slog.Debug("Lyrics upgraded", "id", id)
+		case <-timer.C:
 		}
 
-		mprisPlayer, err := player.Select(conn)
-		if err != nil {
+		players, err := tracker.Players()
+		if errors.Is(err, player.ErrNoPlayer) {
 			slog.Error("Player not found!", "error", err)
-
-			w := waybar.Zero
-			if !w.Is(lastWaybar) {
-				w.Encode()
-				lastWaybar = w
-			}
-
+			emit(waybar.Zero, state.Empty(nil))
 			continue
 		}
-
-		info, err := player.Parse(mprisPlayer)
 		if err != nil {
 			slog.Error("Failed to parse dbus mpris metadata", "error", err)
-			w := waybar.Zero
-			if !w.Is(lastWaybar) {
-				w.Encode()
-				lastWaybar = w
-			}
+			emit(waybar.Zero, state.Empty(nil))
 			continue
 		}
+		mprisPlayer := players[0].Player
 
-		slog.Debug(
-			"PlayerInfo",
-			"id", info.ID,
-			"title", info.Title,
-			"artist", info.Artist,
-			"album", info.Album,
-			"position", info.Position.String(),
-			"length", info.Length.String(),
-		)
-
-		if info.Status == mpris.PlaybackStopped {
-			slog.Info("Player is stopped")
-			w := waybar.Zero
-			if !w.Is(lastWaybar) {
-				w.Encode()
-				lastWaybar = w
-			}
-			continue
-		}
-
-		lyrics, err := lyric.Store.Load(info.ID, false)
+		changed, err := config.UseProfile(player.StripName(mprisPlayer.GetName()))
 		if err != nil {
-			w := waybar.ForPlayer(info)
-			w.Alt = waybar.Getting
-			w.Class = append(w.Class, waybar.Getting)
-			w.Encode()
-			lyrics, err = lyric.GetLyrics(ctx, info)
-			if err != nil {
-				var scoreErr *models.LyricsMatchScoreError
-				if errors.Is(err, models.ErrLyricsNotFound) ||
-					errors.Is(err, models.ErrLyricsNotSynced) ||
-					errors.As(err, &scoreErr) {
-					slog.Info("Lyrics not available", "reason", err)
-				} else {
-					slog.Error(
-						"Failed to get lyrics",
-						"error", err,
-						"lines", lyrics.Lines,
-					)
-				}
-			}
+			slog.Error("Failed to apply player profile", "error", err)
 		}
-
-		// replace load metadata with current
-		lyrics.Metadata = info
-
-		if err != nil || len(lyrics.Lines) == 0 {
-			w := waybar.ForPlayer(info)
-			w.Alt = waybar.NoLyric
-			if !w.Is(lastWaybar) {
-				w.Encode()
-				lastWaybar = w
+		if len(changed) != 0 {
+			slog.Info("Player profile applied", "player", mprisPlayer.GetName(), "changed", changed)
+			// lyrics of other players are processed with the profile of the
+			// first player, so only options used by processing invalidate them
+			if lyric.ProcessingChanged(changed) {
+				lyric.Store.Invalidate()
+			}
+			if err := waybar.CheckFormats(); err != nil {
+				slog.Error("Invalid format in player profile", "error", err)
 			}
-			continue
 		}
 
-		err = info.UpdatePosition(mprisPlayer)
-		if err != nil {
-			slog.Error("Failed to update position", "error", err)
-			continue
+		// each player has its own lyrics, the first shown player is in text
+		// and others are in tooltip
+		views := make([]*waybar.Waybar, len(players))
+		states := make([]state.State, len(players))
+		for i, p := range players {
+			views[i], states[i] = playerState(ctx, p.Metadata, i == 0)
 		}
 
-		var idx int
-		for i, line := range lyrics.Lines {
-			if info.Position <= line.Timestamp {
+		w, s := waybar.Combine(views), states[0]
+		for i, v := range views {
+			if v != waybar.Zero {
+				s = states[i]
 				break
 			}
-			idx = i
 		}
 
-		currentLyric := lyrics.Lines[idx]
-
-		w := waybar.ForLyrics(lyrics, idx)
-		w.Percentage = info.Percentage()
-
-		if info.Status == mpris.PlaybackPaused {
-			w.Paused(info)
-			if !w.Is(lastWaybar) {
+		if emit(w, s) {
+			if line, ok := s.Line(); ok {
 				slog.Info(
 					"Lyrics",
-					"line", currentLyric.Text,
-					"line-time", currentLyric.Timestamp.String(),
-					"position", info.Position.String(),
+					"line", line.Text,
+					"line-time", line.Timestamp.String(),
+					"position", s.Metadata.Position.String(),
 				)
-				w.Encode()
-				lastWaybar = w
 			}
-			continue
 		}
+	}
+}
+
+// playerState returns the Waybar and the state of the player. If primary is
+// true, the Waybar is encoded while the lyrics are fetched. A stopped player is
+// waybar.Zero.
+func playerState(ctx context.Context, info *player.Metadata, primary bool) (*waybar.Waybar, state.State) {
+	slog.Debug(
+		"PlayerInfo",
+		"player", info.Player,
+		"id", info.ID,
+		"title", info.Title,
+		"artist", info.Artist,
+		"album", info.Album,
+		"position", info.Position.String(),
+		"length", info.Length.String(),
+	)
 
-		if currentLyric.Text == "" && len(currentLyric.Words) == 0 {
-			w.SetText(fmt.Sprintf("%s - %s", info.Artist, info.Title))
-			w.Alt = waybar.Music
+	if info.Status == mpris.PlaybackStopped {
+		slog.Info("Player is stopped", "player", info.Player)
+		return waybar.Zero, state.Empty(info)
+	}
+
+	lyrics, err := lyric.Store.Load(info.ID, false)
+	if err != nil && !primary {
+		// lyrics of players in tooltip do not block the main loop, they
+		// are shown when Upgraded is notified
+		lyric.Prefetch(ctx, info)
+		w := waybar.ForPlayer(info)
+		w.Alt = waybar.Getting
+		w.Class = append(w.Class, waybar.Getting)
+		return w, state.Empty(info)
+	}
+	if err != nil {
+		w := waybar.ForPlayer(info)
+		w.Alt = waybar.Getting
+		w.Class = append(w.Class, waybar.Getting)
+		w.Encode()
+		lyrics, err = lyric.StreamLyrics(ctx, info)
+		if err != nil {
+			var scoreErr *models.LyricsMatchScoreError
+			if errors.Is(err, models.ErrLyricsNotFound) ||
+				errors.Is(err, models.ErrLyricsNotSynced) ||
+				errors.As(err, &scoreErr) {
+				slog.Info("Lyrics not available", "reason", err)
+			} else {
+				slog.Error(
+					"Failed to get lyrics",
+					"error", err,
+					"lines", lyrics.Lines,
+				)
+			}
 		}
+	}
+
+	// replace load metadata with current
+	lyrics.Metadata = info
+
+	if err != nil || len(lyrics.Lines) == 0 {
+		w := waybar.ForPlayer(info)
+		w.Alt = waybar.NoLyric
+		return w, state.Empty(info)
+	}
+
+	// lyrics are synced with the position shifted by track offset
+	if offset := lyric.Offsets.Get(info.ID); offset != 0 {
+		shifted := *info
+		shifted.Position += offset
+		lyrics.Metadata = &shifted
+	}
+	position := lyrics.Metadata.Position
 
-		if !w.Is(lastWaybar) {
-			slog.Info(
-				"Lyrics",
-				"line", currentLyric.Text,
-				"line-time", currentLyric.Timestamp.String(),
-				"position", info.Position.String(),
-			)
-			w.Encode()
-			lastWaybar = w
+	var idx int
+	for i, line := range lyrics.Lines {
+		if position <= line.Timestamp {
+			break
 		}
+		idx = i
+	}
+
+	currentLyric := lyrics.Lines[idx]
+
+	w := waybar.ForLyrics(lyrics, idx)
+	w.Percentage = info.Percentage()
+
+	if info.Status == mpris.PlaybackPaused {
+		w.Paused(info)
+	} else if currentLyric.Text == "" && len(currentLyric.Words) == 0 {
+		w.Music(info)
+	}
+
+	return w, state.New(lyrics, idx)
+}
+
+// tickInterval returns the update interval for the state. The interval is
+// shortened while a word synced line or a scrolling line is playing.
+func tickInterval(s state.State) time.Duration {
+	interval := config.UpdateInterval
+
+	line, ok := s.Line()
+	if !ok || s.Metadata == nil || s.Metadata.Status != mpris.PlaybackPlaying {
+		return interval
+	}
+
+	if len(line.Words) != 0 && config.KaraokeInterval > 0 {
+		interval = min(interval, config.KaraokeInterval)
+	}
+	if config.Scroll && str.Width(line.Text) > config.MaxTextLength {
+		interval = min(interval, time.Duration(float64(time.Second)/config.ScrollSpeed))
+	}
+
+	return interval
+}
+
+// wakeInterval returns the duration until the state must be computed again.
+// The main loop wakes up exactly at the next line or word change, and at least
+// every tickInterval.
+func wakeInterval(s state.State) time.Duration {
+	interval := tickInterval(s)
+	if s.Metadata == nil || s.Metadata.Status != mpris.PlaybackPlaying {
+		return interval
+	}
+
+	next, ok := s.NextBoundary()
+	if !ok {
+		return interval
 	}
+	// wake up just after the boundary so the next line or word is current
+	return min(interval, s.Metadata.Remaining(next-s.Metadata.Position)+time.Millisecond)
 }
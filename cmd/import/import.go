@@ -79,6 +79,7 @@ var Command = &cobra.Command{
 			LastUpdate: time.Now(),
 			Score:      1,
 			Lines:      lines,
+			Provider:   "import",
 		}
 
 		return lyric.Store.Save(m)
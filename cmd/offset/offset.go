@@ -0,0 +1,90 @@
+package offset
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/godbus/dbus/v5"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+)
+
+var id string
+
+func init() {
+	Command.Flags().StringVar(&id, "id", id, "Set offset of given track id instead of current track")
+}
+
+// Command is the track offset command.
+var Command = &cobra.Command{
+	Use: "offset",
+	Example: `
+  waybar-lyric offset # Print offset of current track
+  waybar-lyric offset +250ms # Show lyrics 250ms sooner
+  waybar-lyric offset -- -100ms # Show lyrics 100ms later
+  waybar-lyric offset 1s # Set offset to 1 second
+  waybar-lyric offset reset # Remove offset
+  `,
+	Short: "Adjust lyrics timing offset of current track",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		trackID := id
+		if trackID == "" {
+			conn, err := dbus.SessionBus()
+			if err != nil {
+				return fmt.Errorf("failed to create dbus connection: %w", err)
+			}
+			slog.Debug("Created dbus session bus")
+
+			mp, err := player.Select(conn)
+			if err != nil {
+				return fmt.Errorf("failed to select player: %w", err)
+			}
+
+			info, err := player.Parse(mp)
+			if err != nil {
+				return fmt.Errorf("failed to parse player information: %w", err)
+			}
+			trackID = info.ID
+		}
+
+		offset, err := lyric.Offsets.Load(trackID)
+		if err != nil {
+			return fmt.Errorf("failed to load offset: %w", err)
+		}
+
+		if len(args) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), offset)
+			return nil
+		}
+
+		input := args[0]
+		switch {
+		case input == "reset":
+			offset = 0
+		case strings.HasPrefix(input, "+"), strings.HasPrefix(input, "-"):
+			d, err := cast.ToDurationE(input)
+			if err != nil {
+				return fmt.Errorf("failed to convert duration: %w", err)
+			}
+			offset += d
+		default:
+			d, err := cast.ToDurationE(input)
+			if err != nil {
+				return fmt.Errorf("failed to convert duration: %w", err)
+			}
+			offset = d
+		}
+
+		if err := lyric.Offsets.Save(trackID, offset); err != nil {
+			return fmt.Errorf("failed to save offset: %w", err)
+		}
+		slog.Info("Saved lyrics offset", "id", trackID, "offset", offset)
+
+		fmt.Fprintln(cmd.OutOrStdout(), offset)
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package offset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric"
+)
+
+func TestCommand(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	tests := []struct {
+		args     []string
+		expected time.Duration
+	}{
+		{nil, 0},
+		{[]string{"1s"}, time.Second},
+		{[]string{"+250ms"}, 1250 * time.Millisecond},
+		{[]string{"--", "-500ms"}, 750 * time.Millisecond},
+		{nil, 750 * time.Millisecond},
+		{[]string{"reset"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			Command.SetOut(&out)
+			Command.SetArgs(append([]string{"--id", "track"}, tt.args...))
+			if err := Command.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.TrimSpace(out.String()); got != tt.expected.String() {
+				t.Errorf("offset %q printed %q; want %q", tt.args, got, tt.expected)
+			}
+			if got, err := lyric.Offsets.Load("track"); err != nil || got != tt.expected {
+				t.Errorf("Offsets.Load() = %v, %v; want %v", got, err, tt.expected)
+			}
+		})
+	}
+}
@@ -1,25 +1,28 @@
 package cmd
 
 import (
-	"errors"
 	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
 
+	"github.com/Nadim147c/waybar-lyric/cmd/client"
 	"github.com/Nadim147c/waybar-lyric/cmd/export"
 	importcmd "github.com/Nadim147c/waybar-lyric/cmd/import"
 	initcmd "github.com/Nadim147c/waybar-lyric/cmd/init"
 	"github.com/Nadim147c/waybar-lyric/cmd/next"
+	"github.com/Nadim147c/waybar-lyric/cmd/offset"
 	"github.com/Nadim147c/waybar-lyric/cmd/playpause"
 	"github.com/Nadim147c/waybar-lyric/cmd/position"
 	"github.com/Nadim147c/waybar-lyric/cmd/previous"
 	"github.com/Nadim147c/waybar-lyric/cmd/seek"
 	"github.com/Nadim147c/waybar-lyric/cmd/volume"
 	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/waybar"
 	"github.com/carapace-sh/carapace"
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func assertNoErr(err error) {
@@ -30,19 +33,49 @@ func assertNoErr(err error) {
 
 func init() {
 	flags := Command.Flags()
+	flags.BoolVar(&config.Combine, "combine", config.Combine, "Show every eligible player, other players in tooltip")
 	flags.BoolVarP(&config.Compact, "compact", "c", config.Compact, "Output only text content on each line")
 	flags.BoolVarP(&config.Detailed, "detailed", "d", config.Detailed, "Put detailed player information in output")
+	flags.BoolVarP(&config.DBusService, "dbus-service", "D", config.DBusService, "Expose current lyrics as org.waybar_lyric D-Bus service")
+	flags.BoolVar(&config.InterpolatePosition, "interpolate-position", config.InterpolatePosition, "Interpolate position of players which update position in whole seconds")
 	flags.BoolVarP(&config.LyricOnly, "lyric-only", "l", config.LyricOnly, "Display only lyrics in text output")
 	flags.BoolVarP(&config.NoTooltip, "no-tooltip", "T", config.NoTooltip, "Disable tooltip from output")
 	flags.BoolVarP(&config.PrintInit, "init", "i", config.PrintInit, "Display JSON snippet for waybar/config.jsonc")
 	flags.BoolVarP(&config.PrintVersion, "version", "V", config.PrintVersion, "Display waybar-lyric version information")
+	flags.BoolVar(&config.LocalOnly, "local-only", config.LocalOnly, "Use only local lyrics providers and never access the network")
+	flags.BoolVar(&config.Romanize, "romanize", config.Romanize, "Romanize Korean, Cyrillic and kana lyrics without romanization")
+	flags.BoolVar(&config.Scroll, "scroll", config.Scroll, "Scroll long lines instead of truncating them")
 	flags.BoolVarP(&config.ToggleState, "toggle", "t", config.ToggleState, "Toggle player state between pause and resume")
+	flags.Float64Var(&config.ScrollSpeed, "scroll-speed", config.ScrollSpeed, "Set scroll speed in columns per second")
 	flags.IntVarP(&config.BreakTooltip, "break-tooltip", "b", config.BreakTooltip, "Break long lines in tooltip")
-	flags.IntVarP(&config.MaxTextLength, "max-length", "m", config.MaxTextLength, "Set maximum character length for lyrics text")
+	flags.IntVarP(&config.MaxTextLength, "max-length", "m", config.MaxTextLength, "Set maximum display width for lyrics text")
+	flags.IntVar(&config.PlayerSlot, "player-slot", config.PlayerSlot, "Show the Nth eligible player in --players and bus name order (0 is the selected player)")
+	flags.IntVar(&config.ProviderRetries, "provider-retries", config.ProviderRetries, "Set number of retries of failed lyrics provider requests")
+	flags.IntVar(&config.YoulyPlusRace, "youlyplus-race", config.YoulyPlusRace, "Set number of healthiest youlyplus mirrors to query at once (0 queries all)")
 	flags.IntVarP(&config.TooltipLines, "tooltip-lines", "L", config.TooltipLines, "Set maximum number of lines in waybar tooltip")
-	flags.StringArrayVarP(&config.PlayerList, "players", "p", config.PlayerList, "Set list of players to use (order indicates priority)")
+	flags.StringArrayVar(&config.IgnorePlayers, "ignore-player", config.IgnorePlayers, "Ignore players matching name[@host] glob or /regex/ pattern")
+	flags.StringArrayVar(&config.ProviderTimeouts, "provider-timeout", config.ProviderTimeouts, "Set timeout of lyrics provider requests as [name=]timeout (e.g. lrclib=5s)")
+	flags.StringArrayVar(&config.YoulyPlusHosts, "youlyplus-host", config.YoulyPlusHosts, "Set youlyplus API mirrors, or add and remove default mirrors as +url and -url")
+	flags.StringArrayVar(&config.PlayerOffsets, "player-offset", config.PlayerOffsets, "Set position offset of player name or URL host (e.g. YoutubeMusic=1.1s)")
+	flags.StringArrayVarP(&config.PlayerList, "players", "p", config.PlayerList, "Set name[@host] patterns of players to prefer (order indicates priority)")
+	flags.StringSliceVar(&config.Providers, "providers", config.Providers, "Set enabled lyrics providers in order as name[=weight]")
+	flags.StringSliceVar(&config.RequireMeta, "require", config.RequireMeta, "Set metadata required from players (values: album, artist, length)")
+	flags.StringSliceVar(&config.Show, "show", config.Show, "Set parts of lyrics to show (values: original, translation, romanization)")
 	flags.StringVarP(&config.FilterProfanityType, "filter-profanity", "f", config.FilterProfanityType, "Filter profanity from lyrics (values: full, partial)")
+	flags.StringVar(&config.Format, "format", config.Format, "Set text/template format for lyrics text")
+	flags.StringVar(&config.FormatPaused, "format-paused", config.FormatPaused, "Set text/template format for text when paused")
+	flags.StringVar(&config.FormatNoLyric, "format-no-lyric", config.FormatNoLyric, "Set text/template format for text without lyrics")
+	flags.StringVar(&config.TooltipFormat, "tooltip-format", config.TooltipFormat, "Set text/template format for tooltip")
+	flags.StringVar(&config.SelectStrategy, "select", config.SelectStrategy, "Set strategy to select player (values: priority, active, last-changed)")
+	flags.StringVar(&config.ServeAddress, "serve", config.ServeAddress, "Serve lyrics overlay and API on address (e.g. 127.0.0.1:8080)")
+	flags.StringVar(&config.SungColor, "sung-color", config.SungColor, "Set color for sung part of word synced lines")
 	flags.StringVarP(&config.TooltipColor, "tooltip-color", "C", config.TooltipColor, "Set color for inactive lyrics lines")
+	flags.StringVar(&config.UnsungColor, "unsung-color", config.UnsungColor, "Set color for unsung part of word synced lines")
+	flags.DurationVar(&config.KaraokeInterval, "karaoke-interval", config.KaraokeInterval, "Set update interval while a word synced line is playing")
+	flags.DurationVar(&config.LyricsGrace, "lyrics-grace", config.LyricsGrace, "Set time to wait for better lyrics after showing the first result (0 waits for all providers)")
+	flags.DurationVar(&config.LyricsTimeout, "lyrics-timeout", config.LyricsTimeout, "Set timeout for fetching lyrics from all providers")
+	flags.DurationVar(&config.ScrollPause, "scroll-pause", config.ScrollPause, "Set pause at start and end of scrolling")
+	flags.DurationVar(&config.SyncInterval, "sync-interval", config.SyncInterval, "Set interval to re-read player state without any player signal")
 	flags.DurationVarP(&config.UpdateInterval, "update-interval", "u", config.UpdateInterval, "Set updated interval of lyrics")
 
 	assertNoErr(Command.Flags().MarkDeprecated("init", "use 'waybar-lyric init'."))
@@ -55,12 +88,23 @@ func init() {
 	perFlags.BoolVarP(&config.Quiet, "quiet", "q", config.Quiet, "Suppress all log output")
 	perFlags.BoolVarP(&config.Verbose, "verbose", "v", config.Verbose, "Enable verbose logging")
 	perFlags.StringVarP(&config.LogFilePath, "log-file", "o", config.LogFilePath, "Specify file path for saving logs")
+	perFlags.StringVar(&config.Path, "config", config.Path, "Specify configuration file path")
 
 	Command.MarkFlagsMutuallyExclusive("quiet", "verbose")
 	Command.MarkFlagsMutuallyExclusive("quiet", "log-file")
 
+	// daemon shares the display flags with root command
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Deprecated == "" {
+			daemonCommand.Flags().AddFlag(f)
+		}
+	})
+
+	Command.AddCommand(client.Command)
+	Command.AddCommand(daemonCommand)
 	Command.AddCommand(initcmd.Command)
 	Command.AddCommand(next.Command)
+	Command.AddCommand(offset.Command)
 	Command.AddCommand(playpause.Command)
 	Command.AddCommand(position.Command)
 	Command.AddCommand(previous.Command)
@@ -74,6 +118,23 @@ func init() {
 	comp.Standalone()
 	comp.FlagCompletion(carapace.ActionMap{
 		"log-file": carapace.ActionFiles(),
+		"config":   carapace.ActionFiles(".json"),
+		"show": carapace.ActionValues(
+			config.ShowOriginal,
+			config.ShowTranslation,
+			config.ShowRomanization,
+		).UniqueList(","),
+		"providers": carapace.ActionValues(config.ProviderNames...).UniqueList(","),
+		"require": carapace.ActionValues(
+			config.MetadataAlbum,
+			config.MetadataArtist,
+			config.MetadataLength,
+		).UniqueList(","),
+		"select": carapace.ActionValues(
+			config.SelectPriority,
+			config.SelectActive,
+			config.SelectLastChanged,
+		),
 	})
 }
 
@@ -92,17 +153,13 @@ var Command = &cobra.Command{
 		return nil
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-		switch config.FilterProfanityType {
-		case "":
-			config.FilterProfanity = false
-		case "full", "partial":
-			config.FilterProfanity = true
-		default:
-			return errors.New("profanity filter must one of 'full' or 'partial'")
+		// root flags are not part of the sub-command flag set
+		if err := config.Load(cmd.Flags(), cmd.Root().Flags()); err != nil {
+			return err
 		}
 
-		if config.TooltipLines < 4 {
-			return errors.New("tooltip lines limit must be at least 4")
+		if err := waybar.CheckFormats(); err != nil {
+			return err
 		}
 
 		var level log.Level
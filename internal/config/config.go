@@ -1,6 +1,12 @@
 package config
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 var (
 	PrintInit       = false
@@ -17,11 +23,197 @@ var (
 	TooltipLines    = 8
 	TooltipColor    = "#cccccc"
 	PlayerList      = []string{}
+	IgnorePlayers   = []string{}
+	RequireMeta     = []string{MetadataAlbum, MetadataArtist, MetadataLength}
+	SelectStrategy  = SelectPriority
+	PlayerSlot      = 0
+	Combine         = false
 	FilterProfanity = false
 	LogFilePath     = ""
 	UpdateInterval  = time.Second / 4
+	KaraokeInterval = time.Second / 20
+	SyncInterval    = 5 * time.Second
+	SungColor       = ""
+	UnsungColor     = ""
+	Scroll          = false
+	ScrollSpeed     = 8.0
+	ScrollPause     = time.Second
+	Show            = []string{ShowOriginal}
+	Romanize        = false
+	Providers       = slices.Clone(ProviderNames)
+	LocalOnly       = false
+	LyricsTimeout   = 20 * time.Second
+	LyricsGrace     = 3 * time.Second
+	ProviderRetries = 2
+	YoulyPlusRace   = 2
+	DBusService     = false
+	ServeAddress    = ""
+	Format          = ""
+	FormatPaused    = ""
+	FormatNoLyric   = ""
+	TooltipFormat   = ""
+
+	ProviderTimeouts    = []string{DefaultProviderTimeout.String()}
+	YoulyPlusHosts      = slices.Clone(DefaultYoulyPlusHosts)
+	PlayerOffsets       = []string{"YoutubeMusic=1.1s", "music.youtube.com=1.1s"}
+	InterpolatePosition = false
 
 	FilterProfanityType = ""
 
+	// PositionOffsets is the position offsets of players parsed from
+	// PlayerOffsets.
+	PositionOffsets = map[string]time.Duration{}
+	// PlayerPatterns is the player priority parsed from PlayerList.
+	PlayerPatterns = []PlayerPattern{}
+	// IgnorePatterns is the ignored players parsed from IgnorePlayers.
+	IgnorePatterns = []PlayerPattern{}
+	// ProviderWeights is the enabled lyrics providers parsed from Providers.
+	ProviderWeights = []ProviderWeight{}
+
 	Version string
 )
+
+// Parts of the lyrics lines which can be shown.
+const (
+	ShowOriginal     = "original"
+	ShowTranslation  = "translation"
+	ShowRomanization = "romanization"
+)
+
+// Metadata which can be required from players.
+const (
+	MetadataAlbum  = "album"
+	MetadataArtist = "artist"
+	MetadataLength = "length"
+)
+
+// Requires reports whether the metadata is required from players.
+func Requires(metadata string) bool {
+	return slices.Contains(RequireMeta, metadata)
+}
+
+// Strategies to select the player.
+const (
+	SelectPriority    = "priority"
+	SelectActive      = "active"
+	SelectLastChanged = "last-changed"
+)
+
+// Validate validates the options and computes the options derived from them.
+func Validate() error {
+	switch FilterProfanityType {
+	case "":
+		FilterProfanity = false
+	case "full", "partial":
+		FilterProfanity = true
+	default:
+		return errors.New("profanity filter must one of 'full' or 'partial'")
+	}
+
+	if Scroll && ScrollSpeed <= 0 {
+		return errors.New("scroll speed must be positive")
+	}
+
+	if len(Show) == 0 {
+		return errors.New("at least one part of lyrics must be shown")
+	}
+	for _, part := range Show {
+		switch part {
+		case ShowOriginal, ShowTranslation, ShowRomanization:
+		default:
+			return fmt.Errorf("invalid lyrics part to show: %q", part)
+		}
+	}
+
+	for _, metadata := range RequireMeta {
+		switch metadata {
+		case MetadataAlbum, MetadataArtist, MetadataLength:
+		default:
+			return fmt.Errorf("invalid required metadata: %q", metadata)
+		}
+	}
+
+	if PlayerSlot < 0 {
+		return errors.New("player slot must not be negative")
+	}
+	if Combine && PlayerSlot != 0 {
+		return errors.New("player slot can not be used to combine players")
+	}
+
+	switch SelectStrategy {
+	case SelectPriority, SelectActive, SelectLastChanged:
+	default:
+		return fmt.Errorf("invalid player select strategy: %q", SelectStrategy)
+	}
+
+	players, err := parsePlayerPatterns(PlayerList)
+	if err != nil {
+		return err
+	}
+	ignored, err := parsePlayerPatterns(IgnorePlayers)
+	if err != nil {
+		return err
+	}
+	PlayerPatterns, IgnorePatterns = players, ignored
+
+	providers, err := parseProviders(Providers)
+	if err != nil {
+		return err
+	}
+	ProviderWeights = providers
+
+	fallback, timeouts, err := parseProviderTimeouts(ProviderTimeouts)
+	if err != nil {
+		return err
+	}
+
+	if ProviderRetries < 0 {
+		return errors.New("provider retries must not be negative")
+	}
+	if LyricsTimeout <= 0 {
+		return errors.New("lyrics timeout must be positive")
+	}
+	if LyricsGrace < 0 {
+		return errors.New("lyrics grace must not be negative")
+	}
+
+	hosts, err := parseYoulyPlusHosts(YoulyPlusHosts)
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return errors.New("at least one youlyplus host is required")
+	}
+
+	if YoulyPlusRace < 0 {
+		return errors.New("youlyplus race must not be negative")
+	}
+
+	fetchOptions.Store(&FetchOptions{
+		Retries:        ProviderRetries,
+		DefaultTimeout: fallback,
+		Timeouts:       timeouts,
+		YoulyPlusURLs:  hosts,
+		YoulyPlusRace:  YoulyPlusRace,
+	})
+
+	offsets := make(map[string]time.Duration, len(PlayerOffsets))
+	for _, entry := range PlayerOffsets {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("player offset must be in name=offset format: %q", entry)
+		}
+		offset, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid player offset %q: %w", entry, err)
+		}
+		offsets[key] = offset
+	}
+	PositionOffsets = offsets
+
+	if TooltipLines < 4 {
+		return errors.New("tooltip lines limit must be at least 4")
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
+
+// Path is the path of the configuration file. Empty means DefaultPath.
+var Path = ""
+
+// ProfileKey is the key of the per-player profiles section in the
+// configuration file.
+const ProfileKey = "player"
+
+// options maps flag names to their configured values.
+type options map[string]any
+
+var (
+	mu       sync.Mutex
+	flagSets []*pflag.FlagSet
+	file     options
+	profiles map[string]options
+	profile  string
+	// original holds the value of every flag before it was modified by the
+	// configuration file or a profile.
+	original = map[string]any{}
+)
+
+// DefaultPath returns the default configuration file path.
+func DefaultPath() (string, error) {
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %v", err)
+	}
+	return filepath.Join(userConfigDir, "waybar-lyric", "config.json"), nil
+}
+
+// FilePath returns the configuration file path in use.
+func FilePath() (string, error) {
+	if Path != "" {
+		return Path, nil
+	}
+	return DefaultPath()
+}
+
+// Load reads the configuration file and applies it to the flags of the given
+// flag sets. Flags that are set on the command line always take precedence
+// over the configuration file and player profiles. A missing file is ignored
+// unless Path is set explicitly.
+func Load(sets ...*pflag.FlagSet) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	flagSets = sets
+
+	opts, profs, err := readFile()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(opts, profs, profile); err != nil {
+		return err
+	}
+
+	file, profiles = opts, profs
+	return Validate()
+}
+
+// UseProfile applies the profile of given stripped player name on top of the
+// configuration file. It returns names of the options that changed.
+func UseProfile(name string) ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name == profile {
+		return nil, nil
+	}
+
+	before := snapshotAll()
+	profile = name
+	if err := apply(file, profiles, name); err != nil {
+		return nil, err
+	}
+
+	return diff(before, snapshotAll()), Validate()
+}
+
+// Reload reads the configuration file again and applies it on top of the
+// command line flags. On error the previous configuration is kept. It returns
+// names of the options that changed.
+func Reload() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	before := snapshotAll()
+
+	opts, profs, err := readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apply(opts, profs, profile); err != nil {
+		return nil, errors.Join(err, apply(file, profiles, profile))
+	}
+
+	if err := Validate(); err != nil {
+		return nil, errors.Join(err, apply(file, profiles, profile), Validate())
+	}
+
+	file, profiles = opts, profs
+	return diff(before, snapshotAll()), nil
+}
+
+func readFile() (options, map[string]options, error) {
+	path, err := FilePath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && Path == "" {
+		return options{}, map[string]options{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var opts options
+	if err := json.Unmarshal(content, &opts); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	profs := map[string]options{}
+	if raw, ok := opts[ProfileKey]; ok {
+		delete(opts, ProfileKey)
+		sections, err := cast.ToStringMapE(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %q section: %w", ProfileKey, err)
+		}
+		for name, section := range sections {
+			p, err := cast.ToStringMapE(section)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid profile %q: %w", name, err)
+			}
+			profs[name] = p
+		}
+	}
+
+	for key := range opts {
+		if lookup(key) == nil {
+			return nil, nil, fmt.Errorf("unknown config option %q", key)
+		}
+	}
+	for name, p := range profs {
+		for key := range p {
+			if lookup(key) == nil {
+				return nil, nil, fmt.Errorf("unknown config option %q in profile %q", key, name)
+			}
+		}
+	}
+
+	return opts, profs, nil
+}
+
+// apply restores every flag to its original value then applies the options
+// and the profile of the given player on top of it.
+func apply(opts options, profs map[string]options, name string) error {
+	for key, value := range original {
+		if err := setFlag(lookup(key), value); err != nil {
+			return err
+		}
+	}
+	clear(original)
+
+	for _, layer := range []options{opts, profs[name]} {
+		for key, value := range layer {
+			f := lookup(key)
+			if f == nil || f.Changed {
+				continue
+			}
+			if _, ok := original[key]; !ok {
+				original[key] = snapshot(f)
+			}
+			if err := setFlag(f, value); err != nil {
+				return fmt.Errorf("invalid value for config option %q: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func lookup(name string) *pflag.Flag {
+	for _, set := range flagSets {
+		if f := set.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// setFlag sets the value of flag without marking it as changed, so flag group
+// constraints only consider the command line.
+func setFlag(f *pflag.Flag, value any) error {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		s, err := cast.ToStringSliceE(value)
+		if err != nil {
+			return err
+		}
+		return sv.Replace(s)
+	}
+
+	s, err := cast.ToStringE(value)
+	if err != nil {
+		return err
+	}
+	return f.Value.Set(s)
+}
+
+func snapshot(f *pflag.Flag) any {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return slices.Clone(sv.GetSlice())
+	}
+	return f.Value.String()
+}
+
+func snapshotAll() map[string]any {
+	values := map[string]any{}
+	for key := range original {
+		values[key] = snapshot(lookup(key))
+	}
+	for _, p := range profiles {
+		for key := range p {
+			if f := lookup(key); f != nil {
+				values[key] = snapshot(f)
+			}
+		}
+	}
+	return values
+}
+
+func diff(before, after map[string]any) []string {
+	var changed []string
+	for key, value := range after {
+		if fmt.Sprint(before[key]) != fmt.Sprint(value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; ok {
+			continue
+		}
+		// the option is no longer configured, compare with its current value
+		if f := lookup(key); f != nil && fmt.Sprint(before[key]) != fmt.Sprint(snapshot(f)) {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// useFile writes content to a temporary configuration file and returns flag
+// set with text, length and tags flags. State of the configuration file is
+// restored after the test.
+func useFile(t *testing.T, content string) *pflag.FlagSet {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, content)
+
+	oldPath, oldSets, oldFile, oldProfiles, oldProfile := Path, flagSets, file, profiles, profile
+	t.Cleanup(func() {
+		Path, flagSets, file, profiles, profile = oldPath, oldSets, oldFile, oldProfiles, oldProfile
+		clear(original)
+	})
+	Path, profile = path, ""
+	clear(original)
+
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	set.String("text", "default", "")
+	set.Int("length", 10, "")
+	set.StringSlice("tags", []string{"a"}, "")
+	return set
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func flagValues(set *pflag.FlagSet) map[string]string {
+	values := map[string]string{}
+	set.VisitAll(func(f *pflag.Flag) { values[f.Name] = fmt.Sprint(snapshot(f)) })
+	return values
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		args     []string
+		profiles []string
+		want     map[string]string
+		changed  []string
+		wantErr  bool
+	}{
+		{
+			name:    "defaults",
+			content: `{}`,
+			want:    map[string]string{"text": "default", "length": "10", "tags": "[a]"},
+		},
+		{
+			name:    "file",
+			content: `{"text": "file", "length": 20, "tags": ["b", "c"]}`,
+			want:    map[string]string{"text": "file", "length": "20", "tags": "[b c]"},
+		},
+		{
+			name:    "flag over file",
+			content: `{"text": "file", "length": 20}`,
+			args:    []string{"--text", "flag"},
+			want:    map[string]string{"text": "flag", "length": "20", "tags": "[a]"},
+		},
+		{
+			name:     "profile over file",
+			content:  `{"text": "file", "player": {"spotify": {"text": "profile", "tags": ["p"]}}}`,
+			profiles: []string{"spotify"},
+			want:     map[string]string{"text": "profile", "length": "10", "tags": "[p]"},
+			changed:  []string{"tags", "text"},
+		},
+		{
+			name:     "flag over profile",
+			content:  `{"text": "file", "player": {"spotify": {"text": "profile", "length": 30}}}`,
+			args:     []string{"--text", "flag"},
+			profiles: []string{"spotify"},
+			want:     map[string]string{"text": "flag", "length": "30", "tags": "[a]"},
+			changed:  []string{"length"},
+		},
+		{
+			name:     "profile reverted",
+			content:  `{"text": "file", "player": {"spotify": {"text": "profile", "length": 30}}}`,
+			profiles: []string{"spotify", "mpv"},
+			want:     map[string]string{"text": "file", "length": "10", "tags": "[a]"},
+			changed:  []string{"length", "text"},
+		},
+		{
+			name:     "profile switched",
+			content:  `{"player": {"spotify": {"text": "spotify"}, "mpv": {"length": 40}}}`,
+			profiles: []string{"spotify", "mpv"},
+			want:     map[string]string{"text": "default", "length": "40", "tags": "[a]"},
+			changed:  []string{"length", "text"},
+		},
+		{
+			name:    "unknown option",
+			content: `{"txt": "file"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown option in profile",
+			content: `{"player": {"spotify": {"txt": "profile"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			content: `{"length": "long"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			content: `{"text": }`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := useFile(t, tt.content)
+			if err := set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Load(set)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v; want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var changed []string
+			for _, name := range tt.profiles {
+				changed, err = UseProfile(name)
+				if err != nil {
+					t.Fatalf("UseProfile(%q) = %v", name, err)
+				}
+			}
+			if !slices.Equal(changed, tt.changed) {
+				t.Errorf("UseProfile(%q) = %q; want %q", tt.profiles, changed, tt.changed)
+			}
+
+			got := flagValues(set)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("--%s = %q; want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	set := useFile(t, `{}`)
+	Path = filepath.Join(t.TempDir(), "missing.json")
+
+	if err := Load(set); err == nil {
+		t.Error("Load() with missing explicit path succeeded; want error")
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nadim147c/waybar-lyric/internal/match"
+)
+
+// PlayerPattern matches a player by its name and URL host of the track. It is
+// parsed from name[@host], where both name and host are glob or /regex/
+// patterns. Name is matched against the full bus name, the name without the
+// MPRIS prefix (e.g. firefox.instance_1_24) and the name without the instance
+// suffix (e.g. firefox). Empty name matches any player.
+type PlayerPattern struct {
+	Name match.Pattern
+	Host match.Pattern
+}
+
+// ParsePlayerPattern parses name[@host] pattern.
+func ParsePlayerPattern(s string) (PlayerPattern, error) {
+	name, host := s, ""
+	if strings.HasPrefix(s, "/") {
+		// '@' may be a part of the regex
+		if end := strings.LastIndex(s, "/@"); end > 0 {
+			name, host = s[:end+1], s[end+2:]
+		}
+	} else {
+		name, host, _ = strings.Cut(s, "@")
+	}
+
+	var p PlayerPattern
+	var err error
+	if name != "" && name != "*" {
+		if p.Name, err = match.CompilePattern(name); err != nil {
+			return p, fmt.Errorf("invalid player name pattern %q: %w", name, err)
+		}
+	}
+	if host != "" && host != "*" {
+		if p.Host, err = match.CompilePattern(host); err != nil {
+			return p, fmt.Errorf("invalid player host pattern %q: %w", host, err)
+		}
+	}
+	return p, nil
+}
+
+// Match reports whether the pattern matches any of the player names and the
+// host.
+func (p PlayerPattern) Match(names []string, host string) bool {
+	if !p.Host.Match(host) {
+		return false
+	}
+	if p.Name.IsZero() {
+		return true
+	}
+	for _, name := range names {
+		if p.Name.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsHost reports whether the pattern matches the URL host.
+func (p PlayerPattern) NeedsHost() bool {
+	return !p.Host.IsZero()
+}
+
+func parsePlayerPatterns(patterns []string) ([]PlayerPattern, error) {
+	parsed := make([]PlayerPattern, 0, len(patterns))
+	for _, s := range patterns {
+		p, err := ParsePlayerPattern(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
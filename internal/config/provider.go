@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Names of the lyrics providers.
+const (
+	ProviderAsText       = "as_text"
+	ProviderLrcFile      = "lrc_file"
+	ProviderEmbedded     = "embedded"
+	ProviderYoulyPlus    = "youlyplus"
+	ProviderBetterLyrics = "betterlyrics"
+	ProviderSimpMusic    = "simpmusic"
+	ProviderLrclib       = "lrclib"
+)
+
+// ProviderNames is the names of all lyrics providers in default order.
+var ProviderNames = []string{
+	ProviderAsText,
+	ProviderLrcFile,
+	ProviderEmbedded,
+	ProviderYoulyPlus,
+	ProviderBetterLyrics,
+	ProviderSimpMusic,
+	ProviderLrclib,
+}
+
+// localProviders is the providers which read lyrics without network.
+var localProviders = []string{ProviderAsText, ProviderLrcFile, ProviderEmbedded}
+
+// IsLocalProvider reports whether the provider reads lyrics without network.
+func IsLocalProvider(name string) bool {
+	return slices.Contains(localProviders, name)
+}
+
+// ProviderWeight is an enabled lyrics provider and the weight of its score.
+type ProviderWeight struct {
+	Name   string
+	Weight float64
+}
+
+// parseProviders parses name[=weight] entries. Providers are enabled in the
+// order of entries, and the default weight is 1.
+func parseProviders(entries []string) ([]ProviderWeight, error) {
+	parsed := make([]ProviderWeight, 0, len(entries))
+	for _, entry := range entries {
+		name, value, hasWeight := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !slices.Contains(ProviderNames, name) {
+			return nil, fmt.Errorf("unknown lyrics provider: %q", name)
+		}
+		if slices.ContainsFunc(parsed, func(p ProviderWeight) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("duplicate lyrics provider: %q", name)
+		}
+
+		weight := 1.0
+		if hasWeight {
+			w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid weight of lyrics provider %q: %q", name, value)
+			}
+			weight = w
+		}
+
+		if LocalOnly && !IsLocalProvider(name) {
+			continue
+		}
+		parsed = append(parsed, ProviderWeight{Name: name, Weight: weight})
+	}
+	return parsed, nil
+}
+
+// DefaultProviderTimeout is the default timeout of a request attempt of lyrics
+// providers.
+const DefaultProviderTimeout = 10 * time.Second
+
+// FetchOptions is the options read by lyrics providers. Providers keep fetching
+// in background while the options are reloaded, so they read the snapshot
+// returned by Fetch instead of the options.
+type FetchOptions struct {
+	// Retries is the number of retries of failed requests.
+	Retries int
+	// DefaultTimeout is the timeout of providers without timeout in Timeouts.
+	DefaultTimeout time.Duration
+	// Timeouts is the timeouts of providers parsed from ProviderTimeouts.
+	Timeouts map[string]time.Duration
+	// YoulyPlusURLs is the mirrors of the youlyplus API parsed from
+	// YoulyPlusHosts.
+	YoulyPlusURLs []string
+	// YoulyPlusRace is the number of youlyplus mirrors to query at once.
+	YoulyPlusRace int
+}
+
+// ProviderTimeout returns the timeout of a request attempt of the provider.
+func (o *FetchOptions) ProviderTimeout(name string) time.Duration {
+	if timeout, ok := o.Timeouts[name]; ok {
+		return timeout
+	}
+	return o.DefaultTimeout
+}
+
+// fetchOptions is the snapshot of FetchOptions published by Validate.
+var fetchOptions atomic.Pointer[FetchOptions]
+
+func init() {
+	fetchOptions.Store(&FetchOptions{
+		Retries:        ProviderRetries,
+		DefaultTimeout: DefaultProviderTimeout,
+		Timeouts:       map[string]time.Duration{},
+		YoulyPlusURLs:  slices.Clone(DefaultYoulyPlusHosts),
+		YoulyPlusRace:  YoulyPlusRace,
+	})
+}
+
+// Fetch returns the snapshot of the options read by lyrics providers. It is
+// safe to call while the options are reloaded.
+func Fetch() *FetchOptions {
+	return fetchOptions.Load()
+}
+
+// parseProviderTimeouts parses [name=]timeout entries. Entries without name
+// set the timeout of all other providers.
+func parseProviderTimeouts(entries []string) (time.Duration, map[string]time.Duration, error) {
+	fallback := DefaultProviderTimeout
+	timeouts := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			name, value = "", entry
+		}
+		if name != "" && !slices.Contains(ProviderNames, name) {
+			return 0, nil, fmt.Errorf("unknown lyrics provider: %q", name)
+		}
+
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return 0, nil, fmt.Errorf("invalid provider timeout: %q", entry)
+		}
+
+		if name == "" {
+			fallback = timeout
+		} else {
+			timeouts[name] = timeout
+		}
+	}
+	return fallback, timeouts, nil
+}
+
+// DefaultYoulyPlusHosts is the default mirrors of the youlyplus API.
+var DefaultYoulyPlusHosts = []string{
+	"https://lyricsplus.binimum.org",
+	"https://lyricsplus.prjktla.my.id",
+	"https://lyricsplus.prjktla.workers.dev",
+	"https://lyricsplus.atomix.one",
+	"https://lyricsplus-seven.vercel.app",
+}
+
+// parseYoulyPlusHosts parses [+|-]url entries. Entries without prefix replace
+// the default mirrors. If all entries have a prefix, +url adds a mirror to the
+// default mirrors and -url removes it.
+func parseYoulyPlusHosts(entries []string) ([]string, error) {
+	var hosts []string
+	modify := !slices.ContainsFunc(entries, func(e string) bool {
+		return !strings.HasPrefix(e, "+") && !strings.HasPrefix(e, "-")
+	})
+	if modify {
+		hosts = slices.Clone(DefaultYoulyPlusHosts)
+	}
+
+	for _, entry := range entries {
+		op, value := byte('+'), entry
+		if strings.HasPrefix(entry, "+") || strings.HasPrefix(entry, "-") {
+			op, value = entry[0], entry[1:]
+		}
+
+		u, err := url.Parse(strings.TrimSpace(value))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid youlyplus host: %q", entry)
+		}
+		host := u.Scheme + "://" + u.Host
+
+		if op == '-' {
+			hosts = slices.DeleteFunc(hosts, func(h string) bool { return h == host })
+		} else if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
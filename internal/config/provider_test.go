@@ -0,0 +1,112 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseProviders(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []string
+		localOnly bool
+		expected  []ProviderWeight
+		wantErr   bool
+	}{
+		{
+			"order and weight",
+			[]string{"lrclib=1.5", "youlyplus"},
+			false,
+			[]ProviderWeight{{ProviderLrclib, 1.5}, {ProviderYoulyPlus, 1}},
+			false,
+		},
+		{
+			"local only",
+			[]string{"youlyplus", "lrc_file", "embedded=0.5"},
+			true,
+			[]ProviderWeight{{ProviderLrcFile, 1}, {ProviderEmbedded, 0.5}},
+			false,
+		},
+		{"unknown", []string{"genius"}, false, nil, true},
+		{"duplicate", []string{"lrclib", "lrclib=2"}, false, nil, true},
+		{"invalid weight", []string{"lrclib=-1"}, false, nil, true},
+	}
+
+	t.Cleanup(func() { LocalOnly = false })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			LocalOnly = tt.localOnly
+			got, err := parseProviders(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProviders(%q) error = %v; want error %v", tt.entries, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("parseProviders(%q) = %v; want %v", tt.entries, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseYoulyPlusHosts(t *testing.T) {
+	defaults := len(DefaultYoulyPlusHosts)
+
+	tests := []struct {
+		name     string
+		entries  []string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			"replace",
+			[]string{"https://example.com/", "http://localhost:8080"},
+			[]string{"https://example.com", "http://localhost:8080"},
+			false,
+		},
+		{
+			"add",
+			[]string{"+https://example.com"},
+			append(slices.Clone(DefaultYoulyPlusHosts), "https://example.com"),
+			false,
+		},
+		{
+			"remove",
+			[]string{"-" + DefaultYoulyPlusHosts[0] + "/"},
+			DefaultYoulyPlusHosts[1:defaults],
+			false,
+		},
+		{"duplicate", []string{"https://a.com", "https://a.com/"}, []string{"https://a.com"}, false},
+		{"invalid scheme", []string{"ftp://example.com"}, nil, true},
+		{"missing host", []string{"example.com"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYoulyPlusHosts(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseYoulyPlusHosts(%q) error = %v; want error %v", tt.entries, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("parseYoulyPlusHosts(%q) = %v; want %v", tt.entries, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateEmptyYoulyPlusHosts(t *testing.T) {
+	hosts := YoulyPlusHosts
+	t.Cleanup(func() {
+		YoulyPlusHosts = hosts
+		if err := Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	YoulyPlusHosts = nil
+	for _, host := range DefaultYoulyPlusHosts {
+		YoulyPlusHosts = append(YoulyPlusHosts, "-"+host)
+	}
+	if err := Validate(); err == nil {
+		t.Errorf("Validate() with all youlyplus hosts removed = nil; want error")
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask is the inotify events which indicates that configuration file has
+// been written, replaced or removed. The directory is watched instead of the
+// file because most editors replace the file on save.
+const watchMask = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_DELETE
+
+// Watch watches the configuration file using inotify. The returned channel
+// receives a value each time the file is modified until ctx is done. Multiple
+// modifications between two receives are coalesced. A missing configuration
+// directory is created, so a configuration file created later is picked up.
+func Watch(ctx context.Context) (<-chan struct{}, error) {
+	path, err := FilePath()
+	if err != nil {
+		return nil, err
+	}
+	dir, name := filepath.Dir(path), filepath.Base(path)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	if _, err := unix.InotifyAddWatch(fd, dir, watchMask); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	// non-blocking file descriptor uses runtime poller, so Close unblocks Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 16*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("Failed to read inotify events", "error", err)
+				}
+				return
+			}
+
+			var modified bool
+			for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+				event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				start := offset + unix.SizeofInotifyEvent
+				end := start + int(event.Len)
+				if strings.TrimRight(string(buf[start:end]), "\x00") == name {
+					modified = true
+				}
+				offset = end
+			}
+
+			if !modified {
+				continue
+			}
+
+			slog.Debug("Config file modified", "path", path)
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return ch, nil
+}
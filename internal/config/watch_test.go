@@ -0,0 +1,84 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatchReload(t *testing.T) {
+	set := useFile(t, `{}`)
+	// the directory does not exist until Watch creates it
+	Path = filepath.Join(t.TempDir(), "waybar-lyric", "config.json")
+	if err := Load(set); err == nil {
+		t.Fatal("Load() with missing explicit path succeeded; want error")
+	}
+
+	ch, err := Watch(t.Context())
+	if err != nil {
+		t.Fatalf("Watch() = %v", err)
+	}
+
+	wait := func() {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Watch() did not report the modification")
+		}
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"created", `{"text": "file", "length": 20}`, []string{"length", "text"}},
+		{"modified", `{"text": "file", "length": 30}`, []string{"length"}},
+		{"unchanged", `{"length": 30, "text": "file"}`, nil},
+		{"removed", `{"tags": ["b"]}`, []string{"length", "tags", "text"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, Path, tt.content)
+			wait()
+
+			changed, err := Reload()
+			if err != nil {
+				t.Fatalf("Reload() = %v", err)
+			}
+			if !slices.Equal(changed, tt.want) {
+				t.Errorf("Reload() = %q; want %q", changed, tt.want)
+			}
+		})
+	}
+
+	writeFile(t, Path, `{"txt": "file"}`)
+	wait()
+	if _, err := Reload(); err == nil {
+		t.Error("Reload() with unknown option succeeded; want error")
+	}
+	if got := flagValues(set)["tags"]; got != "[b]" {
+		t.Errorf("--tags = %q after failed Reload(); want previous %q", got, "[b]")
+	}
+}
+
+func TestWatchRelativePath(t *testing.T) {
+	useFile(t, `{}`)
+	t.Chdir(filepath.Dir(Path))
+	Path = filepath.Base(Path)
+
+	ch, err := Watch(t.Context())
+	if err != nil {
+		t.Fatalf("Watch() = %v", err)
+	}
+
+	writeFile(t, Path, `{"text": "file"}`)
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not report the modification")
+	}
+}
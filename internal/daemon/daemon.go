@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/gofrs/flock"
+)
+
+// SocketName is the file name of the daemon unix socket.
+const SocketName = "waybar-lyric.sock"
+
+// ErrAlreadyRunning when another daemon owns the socket.
+var ErrAlreadyRunning = errors.New("daemon is already running")
+
+// SocketPath returns the path of the daemon unix socket.
+func SocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, SocketName)
+}
+
+// Listen takes the daemon lock and listens on the daemon socket. The returned
+// function closes the listener and releases the lock.
+func Listen(path string) (net.Listener, func(), error) {
+	lock := flock.New(path + ".lock")
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to take daemon lock: %w", err)
+	}
+	if !locked {
+		return nil, nil, ErrAlreadyRunning
+	}
+
+	// we own the lock, so any existing socket is stale
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		lock.Close()
+		return nil, nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		lock.Close()
+		return nil, nil, err
+	}
+
+	closer := func() {
+		ln.Close()
+		lock.Close()
+	}
+
+	return ln, closer, nil
+}
+
+// spawnTimeout is the maximum duration to wait for a spawned daemon to listen.
+const spawnTimeout = 5 * time.Second
+
+// Dial connects to the daemon socket. If spawn is true and daemon is not
+// running, a new daemon is started in background.
+func Dial(ctx context.Context, path string, spawn bool) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err == nil || !spawn {
+		return conn, err
+	}
+
+	slog.Info("Daemon is not running, starting daemon", "socket", path)
+	if err := Spawn(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, spawnTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("daemon did not start: %w", errors.Join(ctx.Err(), err))
+		case <-ticker.C:
+		}
+
+		conn, err = dialer.DialContext(ctx, "unix", path)
+		if err == nil {
+			return conn, nil
+		}
+	}
+}
+
+// Spawn starts the daemon as a detached process of current executable.
+func Spawn() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"daemon"}
+	if config.LogFilePath != "" {
+		args = append(args, "--log-file", config.LogFilePath)
+	} else {
+		args = append(args, "--quiet")
+	}
+	if config.Path != "" {
+		args = append(args, "--config", config.Path)
+	}
+
+	cmd := exec.Command(exe, args...) //nolint:noctx // daemon must outlive ctx
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	slog.Debug("Daemon started", "pid", cmd.Process.Pid)
+	return cmd.Process.Release()
+}
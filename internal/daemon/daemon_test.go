@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as a fake daemon when it is started by Spawn.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "daemon" {
+		fakeDaemon()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeDaemon serves a single frame to a single client.
+func fakeDaemon() {
+	ln, closer, err := Listen(SocketPath())
+	if err != nil {
+		os.Exit(1)
+	}
+	defer closer()
+
+	ln.(*net.UnixListener).SetDeadline(time.Now().Add(10 * time.Second))
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte("spawned\n"))
+}
+
+func TestDialSpawn(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	path := SocketPath()
+
+	if _, err := Dial(t.Context(), path, false); err == nil {
+		t.Fatal("Dial() without daemon succeeded; want error")
+	}
+
+	conn, err := Dial(t.Context(), path, true)
+	if err != nil {
+		t.Fatalf("Dial() with spawn = %v", err)
+	}
+	defer conn.Close()
+
+	if got := readFrame(t, bufio.NewReader(conn)); got != "spawned\n" {
+		t.Errorf("frame = %q; want %q", got, "spawned\n")
+	}
+}
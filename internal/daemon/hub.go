@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// writeTimeout is the maximum duration to write a frame to a client before the
+// client is dropped.
+const writeTimeout = time.Second
+
+// clientBuffer is the number of frames queued for a client before the client
+// is dropped.
+const clientBuffer = 16
+
+// client is a connected client with its queue of frames.
+type client struct {
+	conn   net.Conn
+	frames chan []byte
+}
+
+// Hub broadcasts encoded Waybar frames to all connected clients. Hub
+// implements io.Writer where each Write is a single frame.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	last    []byte
+}
+
+// NewHub creates a new Hub.
+func NewHub() *Hub {
+	h := new(Hub)
+	h.clients = make(map[*client]struct{})
+	return h
+}
+
+// Write queues the frame for every client and remembers it for new clients.
+// Frames are written by a goroutine of each client, so a slow client does not
+// block Write or other clients.
+func (h *Hub) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.last = slices.Clone(p)
+	for c := range h.clients {
+		select {
+		case c.frames <- h.last:
+		default:
+			slog.Debug("Dropping slow daemon client")
+			h.drop(c)
+		}
+	}
+
+	return len(p), nil
+}
+
+// drop closes and removes the client. The caller must hold the lock.
+func (h *Hub) drop(c *client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	close(c.frames)
+	c.conn.Close()
+}
+
+// remove closes and removes the client.
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+}
+
+// writeFrames writes queued frames to the client until the client is dropped.
+func (h *Hub) writeFrames(c *client) {
+	for p := range c.frames {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)) //nolint:errcheck
+		if _, err := c.conn.Write(p); err != nil {
+			slog.Debug("Dropping daemon client", "error", err)
+			h.remove(c)
+		}
+	}
+}
+
+// Serve accepts clients from the listener until ctx is done.
+func (h *Hub) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	defer h.closeAll()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		slog.Debug("Daemon client connected")
+
+		c := &client{conn: conn, frames: make(chan []byte, clientBuffer)}
+		h.mu.Lock()
+		h.clients[c] = struct{}{}
+		if h.last != nil {
+			c.frames <- h.last
+		}
+		h.mu.Unlock()
+
+		go h.writeFrames(c)
+
+		// clients never send anything, reading only detects disconnection
+		go func() {
+			var buf [1]byte
+			conn.Read(buf[:]) //nolint:errcheck
+			slog.Debug("Daemon client disconnected")
+			h.remove(c)
+		}()
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		h.drop(c)
+	}
+}
@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pipeListener is a net.Listener of in-memory connections.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	select {
+	case <-l.done:
+	default:
+		close(l.done)
+	}
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return &net.UnixAddr{Name: "pipe", Net: "unix"} }
+
+// dial connects a new client to the hub.
+func (l *pipeListener) dial() net.Conn {
+	server, client := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+func serveHub(t *testing.T) (*Hub, *pipeListener) {
+	t.Helper()
+
+	h := NewHub()
+	ln := newPipeListener()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := h.Serve(t.Context(), ln); err != nil && t.Context().Err() == nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		<-done
+	})
+	return h, ln
+}
+
+func clientCount(h *Hub) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func waitClients(t *testing.T, h *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clientCount(h) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("hub has %d clients; want %d", clientCount(h), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	return line
+}
+
+func TestHubFanOut(t *testing.T) {
+	h, ln := serveHub(t)
+
+	h.Write([]byte("first\n"))
+
+	a := ln.dial()
+	defer a.Close()
+	ra := bufio.NewReader(a)
+	if got := readFrame(t, ra); got != "first\n" {
+		t.Errorf("new client frame = %q; want last frame %q", got, "first\n")
+	}
+
+	b := ln.dial()
+	defer b.Close()
+	rb := bufio.NewReader(b)
+	readFrame(t, rb)
+	waitClients(t, h, 2)
+
+	h.Write([]byte("second\n"))
+	for _, r := range []*bufio.Reader{ra, rb} {
+		if got := readFrame(t, r); got != "second\n" {
+			t.Errorf("frame = %q; want %q", got, "second\n")
+		}
+	}
+}
+
+func TestHubDisconnect(t *testing.T) {
+	h, ln := serveHub(t)
+
+	a := ln.dial()
+	b := ln.dial()
+	defer b.Close()
+	waitClients(t, h, 2)
+
+	a.Close()
+	waitClients(t, h, 1)
+
+	h.Write([]byte("frame\n"))
+	if got := readFrame(t, bufio.NewReader(b)); got != "frame\n" {
+		t.Errorf("frame = %q; want %q", got, "frame\n")
+	}
+}
+
+func TestHubSlowClient(t *testing.T) {
+	h, ln := serveHub(t)
+
+	slow := ln.dial() // never reads
+	defer slow.Close()
+	fast := ln.dial()
+	defer fast.Close()
+	waitClients(t, h, 2)
+
+	r := bufio.NewReader(fast)
+	start := time.Now()
+	for range clientBuffer + 2 {
+		h.Write([]byte("frame\n"))
+		readFrame(t, r)
+	}
+	if elapsed := time.Since(start); elapsed >= writeTimeout {
+		t.Errorf("writes took %v with a slow client; want less than %v", elapsed, writeTimeout)
+	}
+
+	waitClients(t, h, 1)
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SocketName)
+
+	ln, closer, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+
+	if _, _, err := Listen(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Listen() = %v; want %v", err, ErrAlreadyRunning)
+	}
+
+	h := NewHub()
+	h.Write([]byte("frame\n"))
+	go h.Serve(t.Context(), ln)
+
+	conn, err := Dial(t.Context(), path, false)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	if got := readFrame(t, bufio.NewReader(conn)); got != "frame\n" {
+		t.Errorf("frame = %q; want %q", got, "frame\n")
+	}
+	conn.Close()
+	closer()
+
+	if _, err := Dial(t.Context(), path, false); err == nil {
+		t.Error("Dial() after close succeeded; want error")
+	}
+
+	_, closer, err = Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() after close = %v", err)
+	}
+	closer()
+}
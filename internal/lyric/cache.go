@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
@@ -22,12 +23,16 @@ var Store = NewCache()
 type Cache struct {
 	mu    sync.Mutex
 	store map[string]models.Lyrics
+	// pending is the unprocessed lyrics saved in background, which are
+	// processed by the next Load.
+	pending map[string]models.Lyrics
 }
 
 // NewCache creates a new instance of Cachhe.
 func NewCache() *Cache {
 	c := new(Cache)
 	c.store = make(map[string]models.Lyrics, CacheSize)
+	c.pending = make(map[string]models.Lyrics)
 	return c
 }
 
@@ -43,6 +48,31 @@ func (s *Cache) NotFound(id string) {
 	s.store[id] = models.Lyrics{}
 }
 
+// processingOptions is the names of the options used to process lyrics on
+// Load.
+var processingOptions = []string{"filter-profanity", "max-length", "romanize", "scroll"}
+
+// ProcessingChanged reports whether any of the changed options is used to
+// process lyrics, i.e. Store must be invalidated.
+func ProcessingChanged(changed []string) bool {
+	return slices.ContainsFunc(changed, func(name string) bool {
+		return slices.Contains(processingOptions, name)
+	})
+}
+
+// Invalidate removes all lyrics from memory so they are processed again with
+// the current options on next Load. Not found entries are kept.
+func (s *Cache) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, lyrics := range s.store {
+		if len(lyrics.Lines) != 0 {
+			delete(s.store, id)
+		}
+	}
+}
+
 // Save saves lyrics to Cache.
 func (s *Cache) Save(lyrics models.Lyrics) error {
 	s.mu.Lock()
@@ -56,11 +86,60 @@ func (s *Cache) Save(lyrics models.Lyrics) error {
 	return s.saveCache(lyrics)
 }
 
-// Load loads lyrics from Cache.
+// Update saves unprocessed lyrics to disk, processes them and saves them to
+// memory. Unlike Save, unprocessed lyrics are never visible in memory. The
+// processed lyrics are returned.
+func (s *Cache) Update(lyrics models.Lyrics) (models.Lyrics, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.saveCache(lyrics)
+	delete(s.pending, lyrics.Metadata.ID)
+
+	CensorLyrics(lyrics)
+	RomanizeLyrics(lyrics)
+	TruncateLyrics(lyrics)
+
+	if len(s.store) == CacheSize {
+		clear(s.store) // clear in memory cache
+	}
+	s.store[lyrics.Metadata.ID] = lyrics
+
+	return lyrics, err
+}
+
+// Stage saves unprocessed lyrics to disk and replaces the lyrics in memory on
+// next Load. Unlike Update, the lyrics are not processed, so it can be called
+// while the options are changed by another goroutine. The lines are copied.
+func (s *Cache) Stage(lyrics models.Lyrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := lyrics
+	pending.Lines = cloneLines(lyrics.Lines)
+	s.pending[lyrics.Metadata.ID] = pending
+	return s.saveCache(lyrics)
+}
+
+// Load loads lyrics from Cache. Staged lyrics are processed first.
 func (s *Cache) Load(id string, diskCache bool) (models.Lyrics, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if lyrics, ok := s.pending[id]; ok {
+		delete(s.pending, id)
+
+		CensorLyrics(lyrics)
+		RomanizeLyrics(lyrics)
+		TruncateLyrics(lyrics)
+
+		if len(s.store) == CacheSize {
+			clear(s.store) // clear in memory cache
+		}
+		s.store[id] = lyrics
+		return lyrics, nil
+	}
+
 	if v, ok := s.store[id]; ok {
 		return v, nil
 	}
@@ -82,6 +161,7 @@ func (s *Cache) Load(id string, diskCache bool) (models.Lyrics, error) {
 	s.store[id] = lyrics
 
 	CensorLyrics(lyrics)
+	RomanizeLyrics(lyrics)
 	TruncateLyrics(lyrics)
 
 	return lyrics, nil
@@ -98,7 +178,7 @@ func (s *Cache) getCacheDir() (string, error) {
 
 // CacheExtension is the extension use for cache files.
 // 1 is the version counter to invalidated old caches.
-const CacheExtension = ".5.json.gz"
+const CacheExtension = ".6.json.gz"
 
 // SaveCache saves the lyrics to cache.
 func (s *Cache) saveCache(lyrics models.Lyrics) error {
@@ -31,9 +31,10 @@ func joinWords(words []models.Word) (models.Word, bool) {
 	}
 
 	return models.Word{
-		Start: words[0].Start,
-		End:   words[len(words)-1].End,
-		Text:  sb.String(),
+		Start:      words[0].Start,
+		End:        words[len(words)-1].End,
+		Text:       sb.String(),
+		Background: words[0].Background,
 	}, true
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,12 +20,18 @@ func Parse(r io.Reader) (models.Lines, error) {
 	scanner := bufio.NewScanner(r)
 
 	lyrics := make(models.Lines, 1) // add empty line a start of the lyrics
+	var offset time.Duration
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
 
+		if ms, ok := parseOffsetTag(line); ok {
+			offset = ms
+			continue
+		}
+
 		var timestamps []time.Duration
 		remaining := line
 		for {
@@ -57,13 +64,15 @@ func Parse(r io.Reader) (models.Lines, error) {
 			before := strings.TrimSpace(temp[:idx])
 			if before == "" {
 				lastTs = currentTs
+				temp = temp[idx+tsLen:]
 				continue
 			}
 
 			words = append(words, models.Word{
-				Start: lastTs,
-				End:   currentTs,
-				Text:  before,
+				Start:      lastTs,
+				End:        currentTs,
+				Text:       before,
+				Background: false,
 			})
 			temp = temp[idx+tsLen:]
 		}
@@ -75,7 +84,8 @@ func Parse(r io.Reader) (models.Lines, error) {
 			for _, word := range words[1:] {
 				withSpace = append(withSpace, models.Word{
 					Start: -1, End: -1,
-					Text: " ",
+					Text:       " ",
+					Background: false,
 				})
 				sb.WriteByte(' ')
 
@@ -89,9 +99,13 @@ func Parse(r io.Reader) (models.Lines, error) {
 
 		for _, ts := range timestamps {
 			lyrics = append(lyrics, models.Line{
-				Timestamp: ts,
-				Text:      remaining,
-				Words:     slices.Clone(withSpace),
+				Timestamp:      ts,
+				Text:           remaining,
+				Words:          slices.Clone(withSpace),
+				Translation:    "",
+				Romanization:   "",
+				RomanizedWords: nil,
+				Agent:          "",
 			})
 		}
 	}
@@ -114,9 +128,46 @@ func Parse(r io.Reader) (models.Lines, error) {
 		return nil, models.ErrLyricsNotSynced
 	}
 
+	shiftLines(lyrics[1:], offset)
+
 	return lyrics, nil
 }
 
+// parseOffsetTag parses [offset:+/-ms] tag.
+func parseOffsetTag(line string) (time.Duration, bool) {
+	tag, ok := strings.CutPrefix(strings.ToLower(line), "[offset:")
+	if !ok {
+		return 0, false
+	}
+	tag, ok = strings.CutSuffix(tag, "]")
+	if !ok {
+		return 0, false
+	}
+	ms, err := strconv.Atoi(strings.TrimSpace(tag))
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// shiftLines shifts timestamps of lines and words by the offset. A positive
+// offset shows lyrics sooner.
+func shiftLines(lines models.Lines, offset time.Duration) {
+	if offset == 0 {
+		return
+	}
+	for i := range lines {
+		lines[i].Timestamp = max(lines[i].Timestamp-offset, 0)
+		for j, word := range lines[i].Words {
+			if word.IsSeparator() {
+				continue
+			}
+			lines[i].Words[j].Start = max(word.Start-offset, 0)
+			lines[i].Words[j].End = max(word.End-offset, 0)
+		}
+	}
+}
+
 const tsLen = len("[mm:ss.xx]")
 
 const oneHundredthOfSecond = time.Second / 100
@@ -0,0 +1,62 @@
+package lrc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   string
+		expected []time.Duration
+	}{
+		{"none", "", []time.Duration{time.Second, 2 * time.Second}},
+		{"positive", "[offset:+500]", []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond}},
+		{"negative", "[offset:-250]", []time.Duration{1250 * time.Millisecond, 2250 * time.Millisecond}},
+		{"clamped", "[offset:1500]", []time.Duration{0, 500 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := ParseText(tt.offset + "\n[00:01.00]first\n[00:02.00]<00:02.00>second <00:03.00>")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 3 {
+				t.Fatalf("len(lines) = %d; want 3", len(lines))
+			}
+			for i, ts := range tt.expected {
+				if lines[i+1].Timestamp != ts {
+					t.Errorf("lines[%d].Timestamp = %v; want %v", i+1, lines[i+1].Timestamp, ts)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLeadingWordTimestamp(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		lines, err := ParseText("[00:01.00]<00:01.00>first <00:01.50>word <00:02.00>")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(lines) != 2 || len(lines[1].Words) == 0 {
+			t.Errorf("ParseText() = %+v; want one word synced line", lines)
+			return
+		}
+		if words := lines[1].Words; words[len(words)-1].End != 2*time.Second {
+			t.Errorf("last word end = %v; want %v", words[len(words)-1].End, 2*time.Second)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParseText() did not return for line starting with word timestamp")
+	}
+}
@@ -50,6 +50,9 @@ func Parse(r io.Reader) (models.Lines, error) {
 	// we always add a empty line in front
 	lines := make(models.Lines, 1)
 
+	translations := getTexts(node, "translation")
+	transliterations := getTexts(node, "transliteration")
+
 	ps := getElemens(filterLines, node)
 	for _, p := range ps {
 		start, _, err := getTimestamps(p.Attr)
@@ -57,16 +60,36 @@ func Parse(r io.Reader) (models.Lines, error) {
 			continue
 		}
 
+		var line models.Line
 		if isLineLevelSynced(p) {
-			lines = append(lines, models.Line{
-				Timestamp: start,
-				Text:      p.FirstChild.Data,
-				Words:     nil,
-			})
-			continue
+			line = models.Line{
+				Timestamp:      start,
+				Text:           p.FirstChild.Data,
+				Words:          nil,
+				Translation:    "",
+				Romanization:   "",
+				RomanizedWords: nil,
+				Agent:          "",
+			}
+		} else {
+			line = collectLineWords(p, start, false)
+		}
+		line.Agent = getAttr(p.Attr, "ttm:agent")
+
+		key := getAttr(p.Attr, "itunes:key")
+		if text, ok := translations[key]; ok && key != "" {
+			line.Translation = getTextContent(text)
+		}
+		if text, ok := transliterations[key]; ok && key != "" {
+			if isLineLevelSynced(text) {
+				line.Romanization = getTextContent(text)
+			} else {
+				romanized := collectLineWords(text, start, false)
+				line.Romanization = romanized.Text
+				line.RomanizedWords = romanized.Words
+			}
 		}
 
-		line := collectLineWords(p, start)
 		lines = append(lines, line)
 	}
 
@@ -77,7 +100,9 @@ func Parse(r io.Reader) (models.Lines, error) {
 	return lines, nil
 }
 
-func collectLineWords(parentNode *html.Node, lineStart time.Duration) models.Line {
+// collectLineWords collects the words of the line. Background vocals (x-bg)
+// are collected with background set to true.
+func collectLineWords(parentNode *html.Node, lineStart time.Duration, background bool) models.Line {
 	var buf bytes.Buffer
 
 	words := make([]models.Word, 0, 5)
@@ -85,7 +110,8 @@ func collectLineWords(parentNode *html.Node, lineStart time.Duration) models.Lin
 		if node.Type == html.TextNode {
 			words = append(words, models.Word{
 				Start: -1, End: -1,
-				Text: node.Data,
+				Text:       node.Data,
+				Background: background,
 			})
 			buf.WriteString(node.Data)
 			continue
@@ -97,7 +123,7 @@ func collectLineWords(parentNode *html.Node, lineStart time.Duration) models.Lin
 		}
 
 		if isBackground(node) {
-			line := collectLineWords(node, start)
+			line := collectLineWords(node, start, true)
 			buf.WriteString(line.Text)
 			words = append(words, line.Words...)
 			continue
@@ -106,7 +132,8 @@ func collectLineWords(parentNode *html.Node, lineStart time.Duration) models.Lin
 		if node.FirstChild == nil {
 			words = append(words, models.Word{
 				Start: start, End: end,
-				Text: "",
+				Text:       "",
+				Background: background,
 			})
 			continue
 		}
@@ -115,16 +142,73 @@ func collectLineWords(parentNode *html.Node, lineStart time.Duration) models.Lin
 
 		words = append(words, models.Word{
 			Start: start, End: end,
-			Text: text,
+			Text:       text,
+			Background: background,
 		})
 		buf.WriteString(text)
 	}
 
 	return models.Line{
-		Timestamp: lineStart,
-		Text:      buf.String(),
-		Words:     words,
+		Timestamp:      lineStart,
+		Text:           buf.String(),
+		Words:          words,
+		Translation:    "",
+		Romanization:   "",
+		RomanizedWords: nil,
+		Agent:          "",
+	}
+}
+
+// getTexts returns the text elements of first iTunesMetadata translation or
+// transliteration element (kind) by their line key.
+func getTexts(node *html.Node, kind string) map[string]*html.Node {
+	texts := map[string]*html.Node{}
+
+	elems := getElemens(func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == kind
+	}, node)
+	if len(elems) == 0 {
+		return texts
+	}
+
+	textElems := getElemens(func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "text"
+	}, elems[0])
+	for _, text := range textElems {
+		if key := getAttr(text.Attr, "for"); key != "" {
+			texts[key] = text
+		}
+	}
+
+	return texts
+}
+
+// getTextContent returns the text of the node and its descendants with
+// collapsed whitespace.
+func getTextContent(node *html.Node) string {
+	var buf strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func getAttr(attrs []html.Attribute, key string) string {
+	i := slices.IndexFunc(attrs, func(a html.Attribute) bool { return a.Key == key })
+	if i < 0 {
+		return ""
 	}
+	return attrs[i].Val
 }
 
 func getTimestamps(attrs []html.Attribute) (start, end time.Duration, err error) {
@@ -29,3 +29,98 @@ func TestParse(t *testing.T) {
 		t.Log(len(lines))
 	})
 }
+
+const translatedTestdata = `<tt xmlns:itunes="http://music.apple.com/lyric-ttml-internal" itunes:timing="Word">
+	<head>
+		<metadata>
+			<iTunesMetadata xmlns="http://music.apple.com/lyric-ttml-internal">
+				<translations>
+					<translation type="subtitle" xml:lang="en">
+						<text for="L1">Hello</text>
+						<text for="L2">Good <span ttm:role="x-bg">night</span></text>
+					</translation>
+				</translations>
+				<transliterations>
+					<transliteration xml:lang="ja-Latn">
+						<text for="L1"><span begin="1" end="2">kon</span><span begin="2" end="3">nichiwa</span></text>
+						<text for="L2">oyasumi</text>
+					</transliteration>
+				</transliterations>
+			</iTunesMetadata>
+		</metadata>
+	</head>
+	<body dur="10">
+		<div>
+			<p begin="1" end="3" itunes:key="L1"><span begin="1" end="2">こん</span><span begin="2" end="3">にちは</span></p>
+			<p begin="4" end="6" itunes:key="L2">おやすみ</p>
+			<p begin="7" end="9" itunes:key="L3">さようなら</p>
+		</div>
+	</body>
+</tt>`
+
+func TestParseTranslations(t *testing.T) {
+	lines, err := ParseText(translatedTestdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("len(lines) = %d; want 4", len(lines))
+	}
+
+	tests := []struct {
+		translation  string
+		romanization string
+		words        int
+	}{
+		{"Hello", "konnichiwa", 2},
+		{"Good night", "oyasumi", 0},
+		{"", "", 0},
+	}
+
+	for i, tt := range tests {
+		line := lines[i+1]
+		if line.Translation != tt.translation {
+			t.Errorf("lines[%d].Translation = %q; want %q", i+1, line.Translation, tt.translation)
+		}
+		if line.Romanization != tt.romanization {
+			t.Errorf("lines[%d].Romanization = %q; want %q", i+1, line.Romanization, tt.romanization)
+		}
+		if len(line.RomanizedWords) != tt.words {
+			t.Errorf("len(lines[%d].RomanizedWords) = %d; want %d", i+1, len(line.RomanizedWords), tt.words)
+		}
+	}
+}
+
+const duetTestdata = `<tt xmlns:ttm="http://www.w3.org/ns/ttml#metadata" itunes:timing="Word">
+	<body dur="10">
+		<div>
+			<p begin="1" end="3" ttm:agent="v1"><span begin="1" end="2">Hello</span> <span ttm:role="x-bg" begin="2" end="3"><span begin="2" end="3">(hey)</span></span></p>
+			<p begin="4" end="6" ttm:agent="v2">Goodbye</p>
+		</div>
+	</body>
+</tt>`
+
+func TestParseAgents(t *testing.T) {
+	lines, err := ParseText(duetTestdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("len(lines) = %d; want 3", len(lines))
+	}
+
+	if lines[1].Agent != "v1" || lines[2].Agent != "v2" {
+		t.Errorf("agents = %q, %q; want v1, v2", lines[1].Agent, lines[2].Agent)
+	}
+
+	words := lines[1].Words
+	if len(words) != 3 {
+		t.Fatalf("len(words) = %d; want 3", len(words))
+	}
+	if words[0].Background || !words[2].Background {
+		t.Errorf("background = %v, %v; want false, true", words[0].Background, words[2].Background)
+	}
+	if lines[1].Text != "Hello (hey)" {
+		t.Errorf("text = %q; want %q", lines[1].Text, "Hello (hey)")
+	}
+}
@@ -24,37 +24,171 @@ import (
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/provider/youlyplus"
 	"github.com/Nadim147c/waybar-lyric/internal/player"
 	"github.com/Nadim147c/waybar-lyric/internal/str"
+	"github.com/Nadim147c/waybar-lyric/internal/transliterate"
 	"github.com/gofrs/flock"
 )
 
 const flockPathPrefix = "/tmp/waybar-lyric"
 
-// lyricTimeout is the timeout duration for lyrics download.
-// TODO: add cli flag for user defined duration.
-const lyricTimeout = 20 * time.Second
-
+// cacheProvider returns the unprocessed lyrics from the disk cache, so they can
+// be saved again.
 var cacheProvider = provider.NewProvider("cache",
 	func(ctx context.Context, metadata *player.Metadata) (models.Lyrics, error) {
-		return Store.Load(metadata.ID, true)
+		return Store.loadCache(metadata.ID)
 	})
 
-var providers = []*provider.LyricProvider{
-	cacheProvider,
-	asText.Provider,
-	lrcFile.Provider,
-	embedded.Provider,
-	youlyplus.Provider,
-	betterlyrics.Provider,
-	simpmusic.Provider,
-	lrclib.Provider,
+// providers is the lyrics providers by their name in config.Providers.
+var providers = map[string]*provider.LyricProvider{
+	config.ProviderAsText:       asText.Provider,
+	config.ProviderLrcFile:      lrcFile.Provider,
+	config.ProviderEmbedded:     embedded.Provider,
+	config.ProviderYoulyPlus:    youlyplus.Provider,
+	config.ProviderBetterLyrics: betterlyrics.Provider,
+	config.ProviderSimpMusic:    simpmusic.Provider,
+	config.ProviderLrclib:       lrclib.Provider,
+}
+
+// fetch fetches lyrics from the provider and sets the priority and weight of
+// the results.
+func fetch(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	p *provider.LyricProvider,
+	priority int,
+	weight float64,
+	metadata *player.Metadata,
+	out chan<- provider.Result,
+) {
+	defer wg.Done()
+
+	var inner sync.WaitGroup
+	results := make(chan provider.Result)
+
+	inner.Add(1)
+	go p.Fetch(ctx, &inner, metadata, results)
+	go func() {
+		inner.Wait()
+		close(results)
+	}()
+
+	for res := range results {
+		res.Priority = priority
+		res.Weight = weight
+		out <- res
+	}
 }
 
 var reArtists = regexp.MustCompile(`(, | and )`)
 
 const MinimumUpgradeInterval = 30 * time.Hour
 
-// GetLyrics returns lyrics for given *player.Info.
+var (
+	upgraded = make(chan string, 1)
+	// Upgraded receives the id of lyrics which are replaced in Store in
+	// background, by a better result after StreamLyrics has returned or by
+	// Prefetch.
+	Upgraded <-chan string = upgraded
+)
+
+// notify notifies Upgraded without blocking.
+func notify(id string) {
+	select {
+	case upgraded <- id:
+	default:
+	}
+}
+
+// fetchOptions is the options of a lyrics fetch. They are read before the
+// fetch starts, so fetches in background do not read the options while they
+// are reloaded.
+type fetchOptions struct {
+	grace   time.Duration
+	timeout time.Duration
+	weights []config.ProviderWeight
+	// stage stages the lyrics in Store instead of processing them.
+	stage bool
+}
+
+func newFetchOptions(grace time.Duration, stage bool) fetchOptions {
+	return fetchOptions{
+		grace:   grace,
+		timeout: config.LyricsTimeout,
+		weights: config.ProviderWeights,
+		stage:   stage,
+	}
+}
+
+// GetLyrics returns lyrics for given *player.Info. It waits for all providers
+// and returns the best result.
 func GetLyrics(ctx context.Context, metadata *player.Metadata) (models.Lyrics, error) {
+	return getLyrics(ctx, metadata, 0)
+}
+
+// StreamLyrics returns the first acceptable lyrics for given *player.Info
+// without waiting for other providers. Cached lyrics are not returned early,
+// since they are only fetched again to be upgraded. The providers are listened
+// for config.LyricsGrace after that, and the lyrics are replaced in Store and
+// Upgraded is notified if better synced lyrics arrive. It waits for all providers
+// like GetLyrics if config.LyricsGrace is zero.
+func StreamLyrics(ctx context.Context, metadata *player.Metadata) (models.Lyrics, error) {
+	return getLyrics(ctx, metadata, config.LyricsGrace)
+}
+
+// prefetching is the ids of lyrics which are being fetched by Prefetch.
+var prefetching = struct {
+	sync.Mutex
+	ids map[string]bool
+}{ids: map[string]bool{}}
+
+// Prefetch fetches lyrics for given *player.Metadata in background like
+// GetLyrics, e.g. for players which are not shown in the text. The lyrics are
+// staged in Store and Upgraded is notified when the fetch is done. It returns
+// false if the lyrics are already being fetched.
+func Prefetch(ctx context.Context, metadata *player.Metadata) bool {
+	id := metadata.ID
+
+	prefetching.Lock()
+	defer prefetching.Unlock()
+	if prefetching.ids[id] {
+		return false
+	}
+	prefetching.ids[id] = true
+
+	// the metadata is modified while fetching
+	m := *metadata
+	opts := newFetchOptions(0, true)
+
+	go func() {
+		defer func() {
+			prefetching.Lock()
+			delete(prefetching.ids, id)
+			prefetching.Unlock()
+		}()
+
+		if err := prefetch(ctx, &m, opts); err != nil {
+			slog.Info("Lyrics not available", "id", id, "reason", err)
+		}
+		notify(id)
+	}()
+
+	return true
+}
+
+func prefetch(ctx context.Context, metadata *player.Metadata, opts fetchOptions) error {
+	// processed lyrics can not be loaded in background
+	lyrics, err := Store.loadCache(metadata.ID)
+	if err == nil && (lyrics.Score > 1 || time.Since(lyrics.LastUpdate) < MinimumUpgradeInterval) {
+		return Store.Stage(lyrics)
+	}
+	_, err = fetchLyrics(ctx, metadata, opts)
+	return err
+}
+
+func getLyrics(
+	ctx context.Context,
+	metadata *player.Metadata,
+	grace time.Duration,
+) (models.Lyrics, error) {
 	uri := metadata.ID
 	lyrics, err := Store.Load(uri, true)
 	if err == nil && lyrics.Score > 1 ||
@@ -63,8 +197,12 @@ func GetLyrics(ctx context.Context, metadata *player.Metadata) (models.Lyrics, e
 	}
 
 	// we try to upgrade
+	return fetchLyrics(ctx, metadata, newFetchOptions(grace, false))
+}
 
-	lockCtx, cancel := context.WithTimeout(ctx, lyricTimeout)
+// fetchLyrics fetches lyrics from the providers and saves the best result.
+func fetchLyrics(ctx context.Context, metadata *player.Metadata, opts fetchOptions) (models.Lyrics, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, opts.timeout)
 	defer cancel()
 
 	lockFile := fmt.Sprintf("%s-%s.lock", flockPathPrefix, metadata.ID)
@@ -74,26 +212,30 @@ func GetLyrics(ctx context.Context, metadata *player.Metadata) (models.Lyrics, e
 		Store.NotFound(metadata.ID)
 		return models.Lyrics{}, fmt.Errorf("failed to take flock for id(%s): %v", metadata.ID, err)
 	}
-	defer os.Remove(lockFile)
-	defer flocker.Close()
+	unlock := func() {
+		flocker.Close()
+		os.Remove(lockFile)
+	}
 
 	if !locked {
+		unlock()
 		return models.Lyrics{}, fmt.Errorf("another instance is trying to download: id(%s)", metadata.ID)
 	}
 
-	if metadata.URL != nil || metadata.URL.Hostname() == "music.youtube.com" {
+	if metadata.URL != nil && metadata.URL.Hostname() == "music.youtube.com" {
 		metadata.RawArtist = reArtists.ReplaceAllLiteralString(metadata.RawArtist, ", ")
 	}
 
 	var wg sync.WaitGroup
 
-	ctx, cancel = context.WithTimeout(ctx, lyricTimeout)
-	defer cancel()
+	fetchCtx, stop := context.WithTimeout(ctx, opts.timeout)
 
 	out := make(chan provider.Result, 10)
-	for _, p := range providers {
+	wg.Add(1)
+	go fetch(fetchCtx, &wg, cacheProvider, 0, 1, metadata, out)
+	for i, p := range opts.weights {
 		wg.Add(1)
-		go p.Fetch(ctx, &wg, metadata, out)
+		go fetch(fetchCtx, &wg, providers[p.Name], i+1, p.Weight, metadata, out)
 	}
 
 	go func() {
@@ -101,66 +243,203 @@ func GetLyrics(ctx context.Context, metadata *player.Metadata) (models.Lyrics, e
 		close(out)
 	}()
 
-	var errs []error
-	var results []provider.Result
-
-	for res := range out {
-		if res.Err != nil {
-			errs = append(errs, err)
-			continue
+	var c collected
+
+	if opts.grace > 0 {
+		var first provider.Result
+		var found bool
+		for res := range out {
+			// cached lyrics are being upgraded, so they are not shown early
+			if c.add(res) && res.Provider != cacheProvider.Name {
+				first, found = res, true
+				break
+			}
 		}
-		if res.Lyrics.Score > 0.5 {
-			results = append(results, res)
+
+		if found {
+			slog.Info("lyrics found early", "provider", first.Provider)
+			synced := provider.WordLevelSyncScore(first.Lyrics.Lines)
+			lyrics, err := save(metadata, first, first.Lyrics.Score+synced)
+
+			go func() {
+				defer unlock()
+				defer stop()
+
+				// word synced lyrics can not be upgraded
+				if synced < 1 {
+					listen(out, &c, opts.grace)
+				}
+				stop()
+				for range out {
+					// drain results of the canceled providers
+				}
+				upgrade(metadata, &c, first)
+			}()
+			return lyrics, err
 		}
 	}
 
-	if len(results) == 0 {
+	defer unlock()
+	defer stop()
+
+	listen(out, &c, 0)
+
+	if len(c.results) == 0 {
 		Store.NotFound(metadata.ID)
-		return models.Lyrics{}, errors.Join(errs...)
+		return models.Lyrics{}, errors.Join(c.errs...)
 	}
 
-	errs = slices.DeleteFunc(errs, func(e error) bool {
+	if err := c.err(); err != nil {
+		slog.Info("One or more provider failed (it is normal)", "error", err)
+	}
+
+	best, score := selectBest(filterOutliers(c.results, 0.7))
+
+	slog.Info("lyrics found", "provider", best.Provider, "word-sync", score > 1)
+
+	if opts.stage {
+		lyrics := prepare(metadata, best, score)
+		return lyrics, Store.Stage(lyrics)
+	}
+	return save(metadata, best, score)
+}
+
+// collected is the results and errors received from the providers.
+type collected struct {
+	results []provider.Result
+	errs    []error
+}
+
+// add adds the result if it is acceptable. It returns true if it is added.
+func (c *collected) add(res provider.Result) bool {
+	if res.Err != nil {
+		c.errs = append(c.errs, res.Err)
+		return false
+	}
+	if res.Lyrics.Score > 0.5 {
+		c.results = append(c.results, res)
+		return true
+	}
+	return false
+}
+
+// err returns the errors of the providers except cancellation.
+func (c *collected) err() error {
+	errs := slices.DeleteFunc(slices.Clone(c.errs), func(e error) bool {
 		return errors.Is(e, context.Canceled)
 	})
+	return errors.Join(errs...)
+}
 
-	err = errors.Join(errs...)
-	if err != nil {
-		slog.Info("One or more provider failed (it is normal)", "error", err)
+// listen adds the results from out until it is closed. It stops early after
+// grace if grace is positive.
+func listen(out <-chan provider.Result, c *collected, grace time.Duration) {
+	if grace <= 0 {
+		for res := range out {
+			c.add(res)
+		}
+		return
 	}
 
-	var best provider.Result
-	score := math.Inf(-1)
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	for {
+		select {
+		case res, ok := <-out:
+			if !ok {
+				return
+			}
+			c.add(res)
+		case <-timer.C:
+			return
+		}
+	}
+}
 
-	filtered := filterOutliers(results, 0.7)
+// upgrade stages the best collected result if it is synced better than the
+// first result (e.g. word synced instead of line synced), and notifies
+// Upgraded. It runs in background, so the lyrics are processed by the next
+// Store.Load instead of here.
+func upgrade(metadata *player.Metadata, c *collected, first provider.Result) {
+	if err := c.err(); err != nil {
+		slog.Debug("One or more provider failed (it is normal)", "error", err)
+	}
 
-	for _, result := range filtered {
-		lyricsScore := provider.WordLevelSyncScore(result.Lyrics.Lines)
-		currentScore := result.Lyrics.Score + lyricsScore
-		if currentScore > score {
-			best = result
-			score = currentScore
-		}
+	best, score := selectBest(filterOutliers(c.results, 0.7))
+	if provider.WordLevelSyncScore(best.Lyrics.Lines) <=
+		provider.WordLevelSyncScore(first.Lyrics.Lines) {
+		return
 	}
 
-	best.Lyrics.Score = score
+	slog.Info("lyrics upgraded", "provider", best.Provider, "word-sync", score > 1)
+	if err := Store.Stage(prepare(metadata, best, score)); err != nil {
+		slog.Error("Failed to save upgraded lyrics", "error", err)
+	}
 
-	slog.Info("lyrics found", "provider", best.Provider, "word-sync", best.Lyrics.Score > 1)
+	notify(metadata.ID)
+}
+
+// save saves the result to Store. It returns the processed lyrics.
+func save(metadata *player.Metadata, result provider.Result, score float64) (models.Lyrics, error) {
+	lyrics, err := Store.Update(prepare(metadata, result, score))
+	if err != nil {
+		return lyrics, fmt.Errorf("failed to save lyrics cache json: %w", err)
+	}
+	return lyrics, nil
+}
 
-	lyrics = best.Lyrics
+// prepare returns the lyrics of the result with the metadata and sorted lines.
+// The lines of the result are not modified. Cached lyrics keep their provider
+// and update time, so they are upgraded again after MinimumUpgradeInterval.
+func prepare(metadata *player.Metadata, result provider.Result, score float64) models.Lyrics {
+	lyrics := result.Lyrics
+	lyrics.Score = score
 	lyrics.Metadata = metadata
-	lyrics.LastUpdate = time.Now()
+	if result.Provider != cacheProvider.Name {
+		lyrics.Provider = result.Provider
+		lyrics.LastUpdate = time.Now()
+	}
+	lyrics.Lines = cloneLines(lyrics.Lines)
 
 	slices.SortFunc(lyrics.Lines, func(a, b models.Line) int {
 		return int((a.Timestamp - b.Timestamp) / time.Millisecond)
 	})
+	return lyrics
+}
 
-	if err := Store.Save(lyrics); err != nil {
-		return lyrics, fmt.Errorf("failed to save lyrics cache json: %w", err)
+// cloneLines returns a deep copy of lines, so processing the copy does not
+// modify the original.
+func cloneLines(lines models.Lines) models.Lines {
+	cloned := make(models.Lines, len(lines))
+	for i, line := range lines {
+		line.Words = slices.Clone(line.Words)
+		line.RomanizedWords = slices.Clone(line.RomanizedWords)
+		cloned[i] = line
 	}
+	return cloned
+}
 
-	CensorLyrics(lyrics)
-	TruncateLyrics(lyrics)
-	return lyrics, nil
+// selectBest returns the result with the highest weighted score and its
+// unweighted score. Word synced lyrics get higher score. Results with equal
+// weighted score are ordered by the provider order in config.Providers.
+func selectBest(results []provider.Result) (provider.Result, float64) {
+	var best provider.Result
+	score, weighted := 0.0, math.Inf(-1)
+
+	for _, result := range results {
+		lyricsScore := provider.WordLevelSyncScore(result.Lyrics.Lines)
+		currentScore := result.Lyrics.Score + lyricsScore
+		currentWeighted := currentScore * result.Weight
+		if currentWeighted > weighted ||
+			currentWeighted == weighted && result.Priority < best.Priority {
+			best = result
+			score = currentScore
+			weighted = currentWeighted
+		}
+	}
+
+	return best, score
 }
 
 // CensorLyrics censors the lyrics with given filtering type.
@@ -171,18 +450,49 @@ func CensorLyrics(lyrics models.Lyrics) {
 
 	for i, line := range lyrics.Lines {
 		lyrics.Lines[i].Text = str.CensorText(line.Text)
-		if len(lyrics.Lines[i].Words) == 0 {
+		lyrics.Lines[i].Translation = str.CensorText(line.Translation)
+		lyrics.Lines[i].Romanization = str.CensorText(line.Romanization)
+		for j, word := range line.Words {
+			lyrics.Lines[i].Words[j].Text = str.CensorText(word.Text)
+		}
+		for j, word := range line.RomanizedWords {
+			lyrics.Lines[i].RomanizedWords[j].Text = str.CensorText(word.Text)
+		}
+	}
+}
+
+// RomanizeLyrics romanizes the lines which are not romanized by the provider.
+// Word synced lines are romanized word by word to keep the timing.
+func RomanizeLyrics(lyrics models.Lyrics) {
+	if !config.Romanize {
+		return
+	}
+
+	for i, line := range lyrics.Lines {
+		if line.Romanization != "" || !transliterate.Romanizable(line.Text) {
 			continue
 		}
-		for j, word := range lyrics.Lines[i].Words {
-			lyrics.Lines[i].Words[j].Text = str.CensorText(word.Text)
+
+		lyrics.Lines[i].Romanization = transliterate.Romanize(line.Text)
+		if len(line.Words) == 0 {
+			continue
+		}
+
+		words := make([]models.Word, len(line.Words))
+		for j, word := range line.Words {
+			word.Text = transliterate.Romanize(word.Text)
+			words[j] = word
 		}
+		lyrics.Lines[i].RomanizedWords = words
 	}
 }
 
-// TruncateLyrics truncates all lines using utf8 character length from user
-// input.
+// TruncateLyrics truncates all lines to the display width from user input.
+// Lines are not truncated when scrolling is enabled.
 func TruncateLyrics(lyrics models.Lyrics) {
+	if config.Scroll {
+		return
+	}
 	for i, l := range lyrics.Lines {
 		lyrics.Lines[i].Text = str.Truncate(l.Text)
 	}
@@ -0,0 +1,404 @@
+package lyric
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/provider"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+)
+
+func TestSelectBest(t *testing.T) {
+	lines := models.Lines{{Timestamp: 0, Text: "line"}}
+	result := func(name string, score float64, priority int, weight float64) provider.Result {
+		return provider.Result{
+			Lyrics:   models.Lyrics{Lines: lines, Score: score},
+			Provider: name,
+			Priority: priority,
+			Weight:   weight,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		results  []provider.Result
+		expected string
+		score    float64
+	}{
+		{
+			"highest score",
+			[]provider.Result{result("a", 0.6, 1, 1), result("b", 0.9, 2, 1)},
+			"b",
+			0.9,
+		},
+		{
+			"weighted",
+			[]provider.Result{result("a", 0.6, 1, 2), result("b", 0.9, 2, 1)},
+			"a",
+			0.6,
+		},
+		{
+			"priority on equal score",
+			[]provider.Result{result("b", 0.8, 2, 1), result("a", 0.8, 1, 1)},
+			"a",
+			0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, score := selectBest(tt.results)
+			if best.Provider != tt.expected || score != tt.score {
+				t.Errorf("selectBest() = %q, %v; want %q, %v", best.Provider, score, tt.expected, tt.score)
+			}
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	lines := models.Lines{{Timestamp: 0, Text: "line"}}
+	result := func(name string, score float64) provider.Result {
+		return provider.Result{
+			Lyrics:   models.Lyrics{Lines: lines, Score: score},
+			Provider: name,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		grace    time.Duration
+		delay    time.Duration
+		expected int
+	}{
+		{"wait for all", 0, 50 * time.Millisecond, 2},
+		{"within grace", time.Second, 10 * time.Millisecond, 2},
+		{"after grace", 10 * time.Millisecond, time.Second, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan provider.Result, 3)
+			out <- result("a", 0.9)
+			out <- result("b", 0.2) // not acceptable
+			go func() {
+				time.Sleep(tt.delay)
+				out <- result("c", 0.8)
+				close(out)
+			}()
+
+			var c collected
+			listen(out, &c, tt.grace)
+			if len(c.results) != tt.expected {
+				t.Errorf("listen() collected %d results; want %d", len(c.results), tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveDoesNotModifyResult(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	lines := models.Lines{
+		{Timestamp: 2 * time.Second, Text: "second"},
+		{Timestamp: time.Second, Text: "first"},
+	}
+	res := provider.Result{
+		Lyrics:   models.Lyrics{Lines: lines, Score: 0.9},
+		Provider: "test",
+	}
+
+	lyrics, err := save(&player.Metadata{ID: "test-save"}, res, 0.9)
+	if err != nil {
+		t.Fatalf("save() error: %v", err)
+	}
+	if lyrics.Lines[0].Text != "first" {
+		t.Errorf("save() first line = %q; want %q", lyrics.Lines[0].Text, "first")
+	}
+	if lines[0].Text != "second" {
+		t.Errorf("save() modified result line = %q; want %q", lines[0].Text, "second")
+	}
+
+	stored, err := Store.Load("test-save", false)
+	if err != nil || stored.Provider != "test" {
+		t.Errorf("Store.Load() = %q, %v; want %q", stored.Provider, err, "test")
+	}
+}
+
+func TestCensorLyrics(t *testing.T) {
+	filter, kind := config.FilterProfanity, config.FilterProfanityType
+	t.Cleanup(func() { config.FilterProfanity, config.FilterProfanityType = filter, kind })
+	config.FilterProfanity, config.FilterProfanityType = true, "full"
+
+	lyrics := models.Lyrics{Lines: models.Lines{{
+		Text:           "oh shit",
+		Translation:    "oh shit",
+		Romanization:   "oh shit",
+		Words:          []models.Word{{Text: "shit"}},
+		RomanizedWords: []models.Word{{Text: "shit"}},
+	}}}
+
+	CensorLyrics(lyrics)
+
+	line := lyrics.Lines[0]
+	for name, got := range map[string]string{
+		"Text":           line.Text,
+		"Translation":    line.Translation,
+		"Romanization":   line.Romanization,
+		"Words":          "oh " + line.Words[0].Text,
+		"RomanizedWords": "oh " + line.RomanizedWords[0].Text,
+	} {
+		if got != "oh ****" {
+			t.Errorf("CensorLyrics() %s = %q; want %q", name, got, "oh ****")
+		}
+	}
+}
+
+// fakeProvider returns a provider which returns the lyrics after the delay.
+func fakeProvider(name string, lyrics models.Lyrics, err error, delay time.Duration) *provider.LyricProvider {
+	return provider.NewProvider(name, func(ctx context.Context, _ *player.Metadata) (models.Lyrics, error) {
+		select {
+		case <-time.After(delay):
+			return lyrics, err
+		case <-ctx.Done():
+			return models.Lyrics{}, ctx.Err()
+		}
+	})
+}
+
+// setProviders replaces the enabled providers for the test.
+func setProviders(t *testing.T, enabled ...*provider.LyricProvider) {
+	t.Helper()
+
+	names := []string{config.ProviderLrclib, config.ProviderYoulyPlus}
+	before, weights := maps.Clone(providers), config.ProviderWeights
+	t.Cleanup(func() { providers, config.ProviderWeights = before, weights })
+
+	config.ProviderWeights = nil
+	for i, p := range enabled {
+		providers[names[i]] = p
+		config.ProviderWeights = append(config.ProviderWeights, config.ProviderWeight{Name: names[i], Weight: 1})
+	}
+}
+
+// useStore replaces Store with an empty cache for the test.
+func useStore(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	store := Store
+	Store = NewCache()
+	t.Cleanup(func() { Store = store })
+}
+
+// testID returns a track id unique to the test.
+func testID(t *testing.T) string {
+	return strings.ReplaceAll(t.Name(), "/", "-")
+}
+
+func TestStreamLyrics(t *testing.T) {
+
+	grace := config.LyricsGrace
+	t.Cleanup(func() { config.LyricsGrace = grace })
+
+	lineSynced := models.Lyrics{Lines: models.Lines{{Timestamp: time.Second, Text: "line"}}, Score: 0.9}
+	wordSynced := models.Lyrics{
+		Lines: models.Lines{{
+			Timestamp: time.Second,
+			Text:      "line",
+			Words:     []models.Word{{Start: time.Second, End: 2 * time.Second, Text: "line"}},
+		}},
+		Score: 0.9,
+	}
+
+	tests := []struct {
+		name      string
+		grace     time.Duration
+		lineDelay time.Duration
+		wordDelay time.Duration
+		returned  string
+		stored    string
+	}{
+		{"upgrade within grace", time.Second, 0, 50 * time.Millisecond, "line", "word"},
+		{"no upgrade after grace", 50 * time.Millisecond, 0, time.Second, "line", "line"},
+		{"word synced first", time.Second, 50 * time.Millisecond, 0, "word", "word"},
+		{"zero grace waits for all", 0, 0, 50 * time.Millisecond, "word", "word"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useStore(t)
+			config.LyricsGrace = tt.grace
+			setProviders(t,
+				fakeProvider("line", lineSynced, nil, tt.lineDelay),
+				fakeProvider("word", wordSynced, nil, tt.wordDelay),
+			)
+			select {
+			case <-Upgraded:
+			default:
+			}
+
+			metadata := &player.Metadata{ID: testID(t)}
+			lyrics, err := StreamLyrics(t.Context(), metadata)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lyrics.Provider != tt.returned {
+				t.Errorf("StreamLyrics() provider = %q; want %q", lyrics.Provider, tt.returned)
+			}
+
+			if tt.returned != tt.stored {
+				select {
+				case id := <-Upgraded:
+					if id != metadata.ID {
+						t.Errorf("Upgraded = %q; want %q", id, metadata.ID)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("lyrics are not upgraded")
+				}
+			} else {
+				// wait for the grace window and cancellation of providers
+				time.Sleep(tt.grace + 100*time.Millisecond)
+				select {
+				case id := <-Upgraded:
+					t.Errorf("Upgraded = %q; want no upgrade", id)
+				default:
+				}
+			}
+
+			stored, err := Store.Load(metadata.ID, false)
+			if err != nil || stored.Provider != tt.stored {
+				t.Errorf("Store.Load() provider = %q, %v; want %q", stored.Provider, err, tt.stored)
+			}
+		})
+	}
+}
+
+func TestStreamLyricsStaleCache(t *testing.T) {
+	useStore(t)
+
+	grace := config.LyricsGrace
+	t.Cleanup(func() { config.LyricsGrace = grace })
+	config.LyricsGrace = time.Second
+
+	metadata := &player.Metadata{ID: testID(t)}
+	updated := time.Now().Add(-2 * MinimumUpgradeInterval).Truncate(time.Second)
+	cached := models.Lyrics{
+		Metadata:   metadata,
+		LastUpdate: updated,
+		Lines:      models.Lines{{Timestamp: time.Second, Text: "cached"}},
+		Score:      0.9,
+		Provider:   "old",
+	}
+	if _, err := Store.Update(cached); err != nil {
+		t.Fatal(err)
+	}
+
+	setProviders(t, fakeProvider("slow", models.Lyrics{}, models.ErrLyricsNotFound, 50*time.Millisecond))
+
+	start := time.Now()
+	lyrics, err := StreamLyrics(t.Context(), metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Errorf("StreamLyrics() returned cached lyrics before providers")
+	}
+	if lyrics.Provider != "old" || !lyrics.LastUpdate.Equal(updated) {
+		t.Errorf("StreamLyrics() = %q updated at %v; want %q updated at %v",
+			lyrics.Provider, lyrics.LastUpdate, "old", updated)
+	}
+}
+
+func TestStageProcessedOnLoad(t *testing.T) {
+	useStore(t)
+
+	length := config.MaxTextLength
+	t.Cleanup(func() { config.MaxTextLength = length })
+
+	text := "a line longer than the display width"
+	metadata := &player.Metadata{ID: testID(t)}
+	lyrics := models.Lyrics{Metadata: metadata, Lines: models.Lines{{Timestamp: time.Second, Text: text}}}
+	if err := Store.Stage(lyrics); err != nil {
+		t.Fatal(err)
+	}
+
+	// options changed after staging are used
+	config.MaxTextLength = 10
+
+	loaded, err := Store.Load(metadata.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Lines[0].Text; got == text || len(got) > len(text) {
+		t.Errorf("Store.Load() text = %q; want truncated", got)
+	}
+
+	raw, err := Store.loadCache(metadata.ID)
+	if err != nil || raw.Lines[0].Text != text {
+		t.Errorf("disk cache text = %q, %v; want unprocessed %q", raw.Lines[0].Text, err, text)
+	}
+	if lyrics.Lines[0].Text != text {
+		t.Errorf("Stage() modified the lyrics: %q", lyrics.Lines[0].Text)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	useStore(t)
+	lyrics := models.Lyrics{Lines: models.Lines{{Timestamp: time.Second, Text: "line"}}, Score: 0.9}
+	setProviders(t, fakeProvider("line", lyrics, nil, 50*time.Millisecond))
+	select {
+	case <-Upgraded:
+	default:
+	}
+
+	metadata := &player.Metadata{ID: testID(t)}
+	if !Prefetch(t.Context(), metadata) {
+		t.Fatal("Prefetch() = false; want true")
+	}
+	if Prefetch(t.Context(), metadata) {
+		t.Error("Prefetch() = true while fetching; want false")
+	}
+	if _, err := Store.Load(metadata.ID, false); err == nil {
+		t.Error("Store.Load() returned lyrics before the fetch is done")
+	}
+
+	select {
+	case id := <-Upgraded:
+		if id != metadata.ID {
+			t.Errorf("Upgraded = %q; want %q", id, metadata.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("prefetched lyrics are not notified")
+	}
+
+	loaded, err := Store.Load(metadata.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Provider != "line" {
+		t.Errorf("Store.Load() provider = %q; want %q", loaded.Provider, "line")
+	}
+}
+
+func TestProcessingChanged(t *testing.T) {
+	tests := []struct {
+		changed []string
+		want    bool
+	}{
+		{nil, false},
+		{[]string{"format", "tooltip-format"}, false},
+		{[]string{"format", "max-length"}, true},
+		{[]string{"filter-profanity"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := ProcessingChanged(tt.changed); got != tt.want {
+			t.Errorf("ProcessingChanged(%q) = %v; want %v", tt.changed, got, tt.want)
+		}
+	}
+}
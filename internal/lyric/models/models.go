@@ -13,12 +13,23 @@ type Line struct {
 	Timestamp time.Duration `json:"time"`
 	Text      string        `json:"line"`
 	Words     []Word        `json:"words,omitzero"`
+
+	// Translation is the translated text of the line.
+	Translation string `json:"translation,omitempty"`
+	// Romanization is the romanized (transliterated) text of the line.
+	Romanization string `json:"romanization,omitempty"`
+	// RomanizedWords is the word synced syllables of the Romanization.
+	RomanizedWords []Word `json:"romanized_words,omitzero"`
+	// Agent is the id of the singer of the line (e.g. v1, v2).
+	Agent string `json:"agent,omitempty"`
 }
 
 type Word struct {
 	Start time.Duration `json:"start"`
 	End   time.Duration `json:"end"`
 	Text  string        `json:"word"`
+	// Background indicates the word is a background vocal.
+	Background bool `json:"background,omitempty"`
 }
 
 func (w Word) IsSeparator() bool { return w.Start == -1 && w.End == -1 }
@@ -32,6 +43,7 @@ type Lyrics struct {
 	LastUpdate time.Time        `json:"last_update"`
 	Lines      Lines            `json:"lyrics"`
 	Score      float64          `json:"score"`
+	Provider   string           `json:"provider,omitempty"`
 }
 
 var (
@@ -0,0 +1,121 @@
+package lyric
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OffsetExtension is the extension of files where track offsets are saved.
+const OffsetExtension = ".offset"
+
+// offsetCheckInterval is the minimum interval between reading the offset file
+// of a track. Offsets are changed by another process with offset command.
+const offsetCheckInterval = time.Second
+
+// Offsets is the store of per track timing offsets. A positive offset shows
+// lyrics sooner.
+var Offsets = NewOffsetStore()
+
+type offsetEntry struct {
+	offset  time.Duration
+	checked time.Time
+}
+
+// OffsetStore loads and saves per track offsets in the cache directory.
+type OffsetStore struct {
+	mu      sync.Mutex
+	entries map[string]offsetEntry
+}
+
+// NewOffsetStore creates a new OffsetStore.
+func NewOffsetStore() *OffsetStore {
+	s := new(OffsetStore)
+	s.entries = make(map[string]offsetEntry)
+	return s
+}
+
+// Get returns the offset of the track. The offset file is read again if it was
+// read more than a second ago.
+func (s *OffsetStore) Get(id string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[id]; ok && time.Since(e.checked) < offsetCheckInterval {
+		return e.offset
+	}
+
+	offset, err := s.Load(id)
+	if err != nil {
+		offset = 0
+	}
+	if len(s.entries) >= CacheSize {
+		clear(s.entries)
+	}
+	s.entries[id] = offsetEntry{offset: offset, checked: time.Now()}
+	return offset
+}
+
+// Load reads the offset of the track from disk. It returns zero offset if the
+// track has no offset.
+func (s *OffsetStore) Load(id string) (time.Duration, error) {
+	path, err := offsetPath(id)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := time.ParseDuration(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset in %s: %w", path, err)
+	}
+	return offset, nil
+}
+
+// Save saves the offset of the track to disk. Zero offset removes the offset
+// file.
+func (s *OffsetStore) Save(id string, offset time.Duration) error {
+	path, err := offsetPath(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.entries, id)
+	s.mu.Unlock()
+
+	if offset == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(offset.String()+"\n"), 0o600)
+}
+
+func offsetPath(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("track id is empty")
+	}
+	cacheDir, err := Store.getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, id+OffsetExtension), nil
+}
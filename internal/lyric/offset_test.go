@@ -0,0 +1,79 @@
+package lyric
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOffsetStore(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		offsets  []time.Duration
+		expected time.Duration
+		exists   bool
+	}{
+		{"missing", nil, 0, false},
+		{"positive", []time.Duration{250 * time.Millisecond}, 250 * time.Millisecond, true},
+		{"negative", []time.Duration{-time.Second}, -time.Second, true},
+		{"changed", []time.Duration{time.Second, 2 * time.Second}, 2 * time.Second, true},
+		{"reset", []time.Duration{time.Second, 0}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOffsetStore()
+			id := "offset-" + tt.name
+
+			// cached before saving, Save must invalidate the entry
+			s.Get(id)
+			for _, offset := range tt.offsets {
+				if err := s.Save(id, offset); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := s.Get(id); got != tt.expected {
+				t.Errorf("Get(%q) = %v; want %v", id, got, tt.expected)
+			}
+			if got, err := NewOffsetStore().Load(id); err != nil || got != tt.expected {
+				t.Errorf("Load(%q) = %v, %v; want %v", id, got, err, tt.expected)
+			}
+
+			path, err := offsetPath(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path); (err == nil) != tt.exists {
+				t.Errorf("offset file exists = %v; want %v", err == nil, tt.exists)
+			}
+		})
+	}
+}
+
+func TestOffsetStoreErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	s := NewOffsetStore()
+	if err := s.Save("", time.Second); err == nil {
+		t.Errorf("Save() with empty id = nil; want error")
+	}
+
+	path := filepath.Join(dir, "waybar-lyric", "invalid"+OffsetExtension)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("soon\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("invalid"); err == nil {
+		t.Errorf("Load() with invalid offset = nil; want error")
+	}
+	if got := s.Get("invalid"); got != 0 {
+		t.Errorf("Get() with invalid offset = %v; want 0", got)
+	}
+}
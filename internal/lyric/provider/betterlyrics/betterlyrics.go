@@ -9,10 +9,10 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/Nadim147c/waybar-lyric/internal/config"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/formats/ttml"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/provider"
-	"github.com/Nadim147c/waybar-lyric/internal/match"
 	"github.com/Nadim147c/waybar-lyric/internal/player"
 )
 
@@ -21,6 +21,8 @@ const (
 	UnisonEndpoint    = "https://unison.boidu.dev/lyrics"
 )
 
+var clients = provider.NewClients(config.ProviderBetterLyrics)
+
 // Provider is the betterlyrics lyrics provider.
 var Provider = provider.NewProvider(
 	"betterlyrics",
@@ -65,13 +67,14 @@ func genericProvider(ctx context.Context, endpoint string, metadata *player.Meta
 	params := url.Values{}
 	params.Set("song", metadata.RawTitle)
 	params.Set("artist", metadata.Artist)
-	params.Set("album", metadata.Album)
+	if metadata.Album != "" {
+		params.Set("album", metadata.Album)
+	}
 	req.URL.RawQuery = params.Encode()
 
 	slog.Info("Fetching lyrics from betterlyrics api", "url", req.URL.String())
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	resp, err := clients.Get(endpoint).Do(req)
 	if err != nil {
 		return models.Lyrics{}, err
 	}
@@ -99,7 +102,7 @@ func genericProvider(ctx context.Context, endpoint string, metadata *player.Meta
 		return models.Lyrics{}, err
 	}
 
-	score := match.Durations(metadata.Length, l)
+	score := provider.DurationScore(metadata, l)
 
 	lines, err := ttml.ParseText(data.TTML)
 	if err != nil {
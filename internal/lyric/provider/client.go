@@ -0,0 +1,270 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+// ErrCircuitOpen when a provider is skipped after repeated failures.
+var ErrCircuitOpen = errors.New("provider is disabled after repeated failures")
+
+// Default options of Client.
+const (
+	DefaultBackoff       = 500 * time.Millisecond
+	DefaultMaxRetryAfter = 5 * time.Second
+	DefaultThreshold     = 3
+	DefaultCooldown      = 5 * time.Minute
+)
+
+// httpClient is the HTTP client shared by all providers. Timeouts are set by
+// the request context.
+var httpClient = &http.Client{} //nolint:exhaustruct
+
+// Client sends HTTP requests of a lyrics provider. Every attempt is limited by
+// the provider timeout, and requests are retried with exponential backoff on
+// server errors and timeouts. Retry-After is respected on 429. The provider is
+// skipped for Cooldown after Threshold consecutive failures. After Cooldown the
+// circuit is half-open: a single trial request is sent, and other requests are
+// skipped until it finishes. A failed trial skips the provider for another
+// Cooldown, and a successful one closes the circuit.
+type Client struct {
+	// Name is the provider name used for config.FetchOptions.ProviderTimeout.
+	Name string
+	// Backoff is the delay before the first retry, doubled on every retry.
+	Backoff time.Duration
+	// MaxRetryAfter is the maximum Retry-After delay to wait for.
+	MaxRetryAfter time.Duration
+	// Threshold is the number of consecutive failures to skip the provider.
+	Threshold int
+	// Cooldown is the duration to skip the provider for.
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial request is in flight
+}
+
+// NewClient creates a new Client with default options for the provider.
+func NewClient(name string) *Client {
+	c := new(Client)
+	c.Name = name
+	c.Backoff = DefaultBackoff
+	c.MaxRetryAfter = DefaultMaxRetryAfter
+	c.Threshold = DefaultThreshold
+	c.Cooldown = DefaultCooldown
+	return c
+}
+
+// Do sends the request. The response body must be closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.allow(time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+
+	// canceled by caller, e.g. lyrics are found by another provider
+	if req.Context().Err() != nil {
+		c.release()
+		return resp, err
+	}
+
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError ||
+		resp.StatusCode == http.StatusTooManyRequests {
+		c.failure(time.Now())
+	} else {
+		c.success()
+	}
+	return resp, err
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	backoff := c.Backoff
+	opts := config.Fetch()
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, opts.ProviderTimeout(c.Name))
+		resp, err := httpClient.Do(req.Clone(attemptCtx))
+
+		wait, retry := c.retryDelay(ctx, resp, err, backoff)
+		if !retry || attempt >= opts.Retries || !fits(ctx, wait) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		cancel()
+
+		slog.Debug("Retrying lyrics request", "provider", c.Name, "url", req.URL.String(), "delay", wait, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// retryDelay returns the delay before retrying the request and whether the
+// request should be retried.
+func (c *Client) retryDelay(
+	ctx context.Context,
+	resp *http.Response,
+	err error,
+	backoff time.Duration,
+) (time.Duration, bool) {
+	if err != nil {
+		return backoff, ctx.Err() == nil && isTimeout(err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			return backoff, true
+		}
+		return wait, wait <= c.MaxRetryAfter
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// allow returns ErrCircuitOpen if the provider is skipped. Only one request is
+// allowed while the circuit is half-open.
+func (c *Client) allow(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Before(c.openUntil) {
+		return ErrCircuitOpen
+	}
+	if c.failures >= c.Threshold {
+		if c.trial {
+			return ErrCircuitOpen
+		}
+		c.trial = true
+	}
+	return nil
+}
+
+// release ends the half-open trial without a result, e.g. when the request is
+// canceled by the caller.
+func (c *Client) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trial = false
+}
+
+func (c *Client) failure(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trial = false
+	c.failures++
+	if c.failures >= c.Threshold {
+		c.openUntil = now.Add(c.Cooldown)
+		slog.Warn("Lyrics provider is disabled after repeated failures",
+			"provider", c.Name, "failures", c.failures, "duration", c.Cooldown)
+	}
+}
+
+func (c *Client) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = 0
+	c.openUntil = time.Time{}
+	c.trial = false
+}
+
+// isTimeout reports whether the err is a timeout of an attempt.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// fits reports whether ctx is not done after waiting for d.
+func fits(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// retryAfter parses Retry-After header in seconds or HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// cancelBody cancels the request context when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Clients is the Clients of a provider with multiple endpoints, so failures of
+// an endpoint do not skip the others.
+type Clients struct {
+	name string
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewClients creates new Clients for the provider.
+func NewClients(name string) *Clients {
+	c := new(Clients)
+	c.name = name
+	c.clients = make(map[string]*Client)
+	return c
+}
+
+// Get returns the Client of the endpoint.
+func (c *Clients) Get(endpoint string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, ok := c.clients[endpoint]
+	if !ok {
+		client = NewClient(c.name)
+		c.clients[endpoint] = client
+	}
+	return client
+}
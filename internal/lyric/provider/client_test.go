@@ -0,0 +1,273 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+// setClientConfig sets provider timeout and retries for the test.
+func setClientConfig(t *testing.T, timeout time.Duration, retries int) {
+	t.Helper()
+
+	timeouts, retriesBefore := config.ProviderTimeouts, config.ProviderRetries
+	config.ProviderTimeouts = []string{timeout.String()}
+	config.ProviderRetries = retries
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		config.ProviderTimeouts, config.ProviderRetries = timeouts, retriesBefore
+		if err := config.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func newTestClient() *Client {
+	c := NewClient("test")
+	c.Backoff = time.Millisecond
+	c.Cooldown = time.Minute
+	return c
+}
+
+// serve starts a server which responds with statuses in order and counts the
+// requests.
+func serve(t *testing.T, handler func(w http.ResponseWriter, n int)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		handler(w, int(count.Add(1)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func get(t *testing.T, ctx context.Context, c *Client, url string) (int, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestClientRetry(t *testing.T) {
+	setClientConfig(t, time.Second, 2)
+
+	tests := []struct {
+		name     string
+		statuses []int
+		expected int
+		requests int32
+	}{
+		{"success", []int{200}, 200, 1},
+		{"not found", []int{404}, 404, 1},
+		{"server error", []int{500, 502, 200}, 200, 3},
+		{"retries exhausted", []int{503, 503, 503, 200}, 503, 3},
+		{"too many requests", []int{429, 200}, 200, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, count := serve(t, func(w http.ResponseWriter, n int) {
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			})
+
+			status, err := get(t, t.Context(), newTestClient(), srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.expected || count.Load() != tt.requests {
+				t.Errorf("Do() = %d after %d requests; want %d after %d requests",
+					status, count.Load(), tt.expected, tt.requests)
+			}
+		})
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	setClientConfig(t, time.Second, 2)
+
+	t.Run("wait", func(t *testing.T) {
+		srv, count := serve(t, func(w http.ResponseWriter, n int) {
+			if n == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		start := time.Now()
+		status, err := get(t, t.Context(), newTestClient(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || count.Load() != 2 {
+			t.Errorf("Do() = %d after %d requests; want 200 after 2 requests", status, count.Load())
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("Do() retried after %v; want at least Retry-After 1s", elapsed)
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		srv, count := serve(t, func(w http.ResponseWriter, _ int) {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		status, err := get(t, t.Context(), newTestClient(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusTooManyRequests || count.Load() != 1 {
+			t.Errorf("Do() = %d after %d requests; want 429 after 1 request", status, count.Load())
+		}
+	})
+}
+
+func TestClientTimeout(t *testing.T) {
+	setClientConfig(t, 50*time.Millisecond, 1)
+
+	srv, count := serve(t, func(w http.ResponseWriter, n int) {
+		if n == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status, err := get(t, t.Context(), newTestClient(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK || count.Load() != 2 {
+		t.Errorf("Do() = %d after %d requests; want 200 after 2 requests", status, count.Load())
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	setClientConfig(t, time.Second, 0)
+
+	srv, count := serve(t, func(w http.ResponseWriter, _ int) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	c := newTestClient()
+	for range c.Threshold {
+		if _, err := get(t, t.Context(), c, srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := get(t, t.Context(), c, srv.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Do() error = %v; want %v", err, ErrCircuitOpen)
+	}
+	if count.Load() != int32(c.Threshold) {
+		t.Errorf("server got %d requests; want %d", count.Load(), c.Threshold)
+	}
+
+	// provider is used again after cooldown
+	if err := c.allow(time.Now().Add(c.Cooldown)); err != nil {
+		t.Errorf("allow() after cooldown = %v; want nil", err)
+	}
+}
+
+func TestClientHalfOpen(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		trial   func(c *Client, now time.Time)
+		allowed bool
+	}{
+		{"failed trial opens circuit", func(c *Client, now time.Time) { c.failure(now) }, false},
+		{"successful trial closes circuit", func(c *Client, _ time.Time) { c.success() }, true},
+		{"canceled trial allows another", func(c *Client, _ time.Time) { c.release() }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient()
+			for range c.Threshold {
+				c.failure(now)
+			}
+
+			later := now.Add(c.Cooldown)
+			if err := c.allow(later); err != nil {
+				t.Fatalf("allow() trial after cooldown = %v; want nil", err)
+			}
+			if err := c.allow(later); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("allow() during trial = %v; want %v", err, ErrCircuitOpen)
+			}
+
+			tt.trial(c, later)
+
+			err := c.allow(later.Add(time.Second))
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("allow() after trial = %v; want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestClientCanceled(t *testing.T) {
+	setClientConfig(t, time.Second, 2)
+
+	srv, _ := serve(t, func(w http.ResponseWriter, _ int) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	c := newTestClient()
+	for range c.Threshold {
+		if _, err := get(t, ctx, c, srv.URL); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Do() error = %v; want %v", err, context.Canceled)
+		}
+	}
+	if err := c.allow(time.Now()); err != nil {
+		t.Errorf("allow() after canceled requests = %v; want nil", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"Wed, 01 Jan 2025 00:00:10 GMT", 10 * time.Second, true},
+		{"Tue, 31 Dec 2024 23:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := retryAfter(tt.value, now)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/gofrs/flock"
+)
+
+// Default options of Health.
+const (
+	DefaultDropThreshold = 3
+	DefaultDropDuration  = 30 * time.Minute
+)
+
+// latencySmoothing is the weight of the latest request in the average latency.
+const latencySmoothing = 0.3
+
+// HostStats is the request statistics of an endpoint.
+type HostStats struct {
+	Successes int `json:"successes"`
+	Failures  int `json:"failures"`
+	// Streak is the number of consecutive failures.
+	Streak int `json:"streak"`
+	// Latency is the moving average latency of successful requests.
+	Latency     time.Duration `json:"latency"`
+	LastFailure time.Time     `json:"last_failure,omitzero"`
+}
+
+// rate returns the smoothed success rate, 0.5 for unknown endpoints.
+func (s HostStats) rate() float64 {
+	return float64(s.Successes+1) / float64(s.Successes+s.Failures+2)
+}
+
+// score returns the health score of the endpoint. Higher is healthier.
+func (s HostStats) score() float64 {
+	return s.rate() / (1 + s.Latency.Seconds())
+}
+
+// Health tracks the statistics of the endpoints of a provider and saves them
+// to the cache directory, so healthy endpoints are preferred across runs. The
+// statistics are shared by all running instances: they are read again before
+// ordering, and every record is merged into the file under a file lock.
+// Endpoints are dropped for DropDuration after DropThreshold consecutive
+// failures.
+type Health struct {
+	// Name is the name of the provider and the statistics file.
+	Name string
+	// DropThreshold is the number of consecutive failures to drop an endpoint.
+	DropThreshold int
+	// DropDuration is the duration to drop an endpoint for.
+	DropDuration time.Duration
+
+	mu    sync.Mutex
+	stats map[string]HostStats
+}
+
+// NewHealth creates a new Health with default options for the provider.
+func NewHealth(name string) *Health {
+	h := new(Health)
+	h.Name = name
+	h.DropThreshold = DefaultDropThreshold
+	h.DropDuration = DefaultDropDuration
+	h.stats = make(map[string]HostStats)
+	return h
+}
+
+// dropped reports whether the endpoint is dropped at time now.
+func (h *Health) dropped(s HostStats, now time.Time) bool {
+	return s.Streak >= h.DropThreshold && now.Before(s.LastFailure.Add(h.DropDuration))
+}
+
+// Order returns the endpoints ordered by health. Dropped endpoints are
+// removed, unless all endpoints are dropped. Endpoints with equal health keep
+// their order.
+func (h *Health) Order(endpoints []string, now time.Time) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.load()
+
+	ordered := slices.DeleteFunc(slices.Clone(endpoints), func(e string) bool {
+		return h.dropped(h.stats[e], now)
+	})
+	if len(ordered) == 0 {
+		ordered = slices.Clone(endpoints)
+	}
+
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return cmp.Compare(h.stats[b].score(), h.stats[a].score())
+	})
+	return ordered
+}
+
+// Record records the result of a request to the endpoint and merges it into
+// the saved statistics. Requests canceled by the caller or skipped by the
+// Client are not recorded, and not found lyrics are recorded as success.
+func (h *Health) Record(endpoint string, latency time.Duration, err error, now time.Time) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unlock, lockErr := h.lock()
+	if lockErr != nil {
+		slog.Debug("Failed to lock provider health", "provider", h.Name, "error", lockErr)
+	} else {
+		defer unlock()
+	}
+	h.load()
+
+	s := h.stats[endpoint]
+	if err == nil || errors.Is(err, models.ErrLyricsNotFound) {
+		s.Successes++
+		s.Streak = 0
+		if s.Latency == 0 {
+			s.Latency = latency
+		} else {
+			s.Latency += time.Duration(latencySmoothing * float64(latency-s.Latency))
+		}
+	} else {
+		s.Failures++
+		s.Streak++
+		s.LastFailure = now
+		if s.Streak == h.DropThreshold {
+			slog.Warn("Lyrics provider endpoint is dropped after repeated failures",
+				"provider", h.Name, "endpoint", endpoint, "duration", h.DropDuration)
+		}
+	}
+	h.stats[endpoint] = s
+
+	if err := h.save(); err != nil {
+		slog.Debug("Failed to save provider health", "provider", h.Name, "error", err)
+	}
+}
+
+// Stats returns the statistics of the endpoint.
+func (h *Health) Stats(endpoint string) HostStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.load()
+	return h.stats[endpoint]
+}
+
+func (h *Health) path() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %v", err)
+	}
+	return filepath.Join(cacheDir, "waybar-lyric", h.Name+".health.json"), nil
+}
+
+// lock takes the file lock of the statistics. It returns the function to
+// release the lock.
+func (h *Health) lock() (func(), error) {
+	path, err := h.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	return func() { lock.Close() }, nil
+}
+
+// load loads the statistics saved by all instances from disk. The statistics
+// in memory are kept if they are missing or invalid on disk.
+func (h *Health) load() {
+	path, err := h.path()
+	if err != nil {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	stats := make(map[string]HostStats)
+	if err := json.Unmarshal(b, &stats); err != nil {
+		slog.Debug("Invalid provider health", "path", path, "error", err)
+		return
+	}
+	h.stats = stats
+}
+
+// save saves the statistics to disk. The file is replaced atomically so other
+// instances never read partial statistics.
+func (h *Health) save() error {
+	path, err := h.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(h.stats)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
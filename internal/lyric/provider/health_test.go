@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+)
+
+func TestHealthOrder(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	now := time.Now()
+	failure := errors.New("bad gateway")
+	hosts := []string{"a", "b", "c", "d"}
+
+	tests := []struct {
+		name     string
+		record   func(h *Health)
+		expected []string
+	}{
+		{"unknown keeps order", func(*Health) {}, hosts},
+		{
+			"healthy first",
+			func(h *Health) {
+				h.Record("a", time.Second, failure, now)
+				h.Record("c", 100*time.Millisecond, nil, now)
+				h.Record("d", 100*time.Millisecond, models.ErrLyricsNotFound, now)
+			},
+			[]string{"c", "d", "b", "a"},
+		},
+		{
+			"faster first and slow after unknown",
+			func(h *Health) {
+				h.Record("a", 2*time.Second, nil, now)
+				h.Record("b", 100*time.Millisecond, nil, now)
+			},
+			[]string{"b", "c", "d", "a"},
+		},
+		{
+			"drop failing",
+			func(h *Health) {
+				for range DefaultDropThreshold {
+					h.Record("b", time.Second, failure, now)
+				}
+			},
+			[]string{"a", "c", "d"},
+		},
+		{
+			"canceled is ignored",
+			func(h *Health) {
+				for range DefaultDropThreshold {
+					h.Record("a", time.Second, ErrCircuitOpen, now)
+				}
+			},
+			hosts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth(tt.name)
+			tt.record(h)
+			if got := h.Order(hosts, now); !slices.Equal(got, tt.expected) {
+				t.Errorf("Order() = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHealthDropExpires(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	now := time.Now()
+	h := NewHealth("test")
+	for range DefaultDropThreshold {
+		h.Record("a", time.Second, errors.New("timeout"), now)
+	}
+
+	if got := h.Order([]string{"a", "b"}, now); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Order() = %v; want [b]", got)
+	}
+	if got := h.Order([]string{"a"}, now); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Order() with all dropped = %v; want [a]", got)
+	}
+	later := now.Add(DefaultDropDuration + time.Second)
+	if got := h.Order([]string{"a", "b"}, later); !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("Order() after drop = %v; want [b a]", got)
+	}
+}
+
+func TestHealthPersist(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	h := NewHealth("test")
+	h.Record("a", 200*time.Millisecond, nil, time.Now())
+	h.Record("a", 400*time.Millisecond, nil, time.Now())
+
+	got := NewHealth("test").Stats("a")
+	if got.Successes != 2 || got.Latency != 260*time.Millisecond {
+		t.Errorf("Stats() = %+v; want 2 successes and 260ms latency", got)
+	}
+}
+
+func TestHealthMergeInstances(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	first, second := NewHealth("test"), NewHealth("test")
+	first.Order([]string{"a"}, time.Now()) // load before the other instance saves
+	second.Record("a", time.Second, nil, time.Now())
+	first.Record("b", time.Second, nil, time.Now())
+	second.Record("a", time.Second, nil, time.Now())
+
+	for _, h := range []*Health{first, second, NewHealth("test")} {
+		if a, b := h.Stats("a"), h.Stats("b"); a.Successes != 2 || b.Successes != 1 {
+			t.Errorf("Stats() = %d and %d successes; want 2 and 1", a.Successes, b.Successes)
+		}
+	}
+}
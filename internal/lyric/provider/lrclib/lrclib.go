@@ -32,12 +32,16 @@ type response struct {
 // Endpoint is api endpoint for lrclib.
 const Endpoint = "https://lrclib.net/api/search"
 
+var client = provider.NewClient(config.ProviderLrclib)
+
 // Provider is a lyrics provider that fetches lyrics from lrclib.
 var Provider = provider.NewProvider("lrclib lyrics api",
 	func(ctx context.Context, metadata *player.Metadata) (models.Lyrics, error) {
 		params := url.Values{}
 		params.Set("track_name", metadata.RawTitle)
-		params.Set("artist_name", metadata.RawArtist)
+		if metadata.RawArtist != "" {
+			params.Set("artist_name", metadata.RawArtist)
+		}
 
 		header := http.Header{}
 		header.Set("User-Agent", config.Version)
@@ -52,7 +56,6 @@ var Provider = provider.NewProvider("lrclib lyrics api",
 
 		slog.Info("Fetching lyrics from Lrclib", "url", req.URL.String())
 
-		client := http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			return models.Lyrics{}, err
@@ -17,6 +17,10 @@ type Result struct {
 	Lyrics   models.Lyrics
 	Provider string
 	Err      error
+	// Priority is the order of the provider, lower is preferred on equal score.
+	Priority int
+	// Weight is the weight of the provider score in selection.
+	Weight float64
 }
 
 // WordLevelSyncScore returns score of lines sync.
@@ -66,12 +70,49 @@ type LyricsResult struct {
 	Duration time.Duration
 }
 
+// Weights of the metadata in Score.
+const (
+	durationWeight = 2
+	titleWeight    = 2
+	artistWeight   = 1
+	albumWeight    = 1
+	totalWeight    = durationWeight + titleWeight + artistWeight + albumWeight
+)
+
+// maxScale is the maximum scale of the score when metadata is missing from the
+// track. A title alone can not reach the full score, so it must match closely
+// to reach MinimumScore.
+const maxScale = 2
+
+// UnknownDurationScore is the score of DurationScore when the track length is
+// unknown.
+const UnknownDurationScore = 0.75
+
+// DurationScore returns the similarity of track length and lyrics duration. It
+// returns UnknownDurationScore if the player does not report the track length.
+func DurationScore(track *player.Metadata, duration time.Duration) float64 {
+	if track.Length <= 0 {
+		return UnknownDurationScore
+	}
+	return match.Durations(track.Length, duration)
+}
+
 // Score calculates a similarity score between an MPRIS track and a LyricsResult
 // to determine if the lyrics are suitable for the current track. Returns true
-// is lyrics is suitable.
+// is lyrics is suitable. Length, artist and album missing from the track are
+// not scored and the score of the rest is scaled up to the same range, at most
+// by maxScale.
 func Score(track *player.Metadata, result LyricsResult) float64 {
-	durationScore := match.Durations(track.Length, result.Duration) * 2
-	titleScore := match.Strings(track.RawTitle, result.Title) * 2
+	weight := float64(titleWeight)
+
+	var durationScore float64
+	if track.Length > 0 {
+		durationScore = match.Durations(track.Length, result.Duration) * durationWeight
+		weight += durationWeight
+	}
+
+	titleScore := match.Strings(track.RawTitle, result.Title) * titleWeight
+
 	var artistsScore float64
 	if len(track.Artists) > 1 {
 		var separate float64
@@ -79,17 +120,25 @@ func Score(track *player.Metadata, result LyricsResult) float64 {
 			separate += match.Strings(artist, result.Artist)
 		}
 		joined := match.Strings(strings.Join(track.Artists, ", "), result.Artist)
-		artistsScore = max(separate, joined)
-	} else {
-		artistsScore = match.Strings(track.RawArtist, result.Artist)
+		artistsScore = max(separate, joined) * artistWeight
+		weight += artistWeight
+	} else if track.RawArtist != "" {
+		artistsScore = match.Strings(track.RawArtist, result.Artist) * artistWeight
+		weight += artistWeight
+	}
+
+	var albumScore float64
+	if track.Album != "" {
+		albumScore = match.Strings(track.Album, result.Album) * albumWeight
+		weight += albumWeight
 	}
-	albumScore := match.Strings(track.Album, result.Album)
 
-	score := durationScore + titleScore + albumScore + artistsScore
+	score := (durationScore + titleScore + albumScore + artistsScore) * min(totalWeight/weight, maxScale)
 
 	slog.Debug(
 		"SmartMatch",
 		"score", score,
+		"weight", weight,
 		"album_want", track.Album,
 		"album_got", result.Album,
 		"album_score", albumScore,
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+)
+
+func TestScore(t *testing.T) {
+	result := LyricsResult{
+		Title:    "Song",
+		Artist:   "Artist",
+		Album:    "Album",
+		Duration: 3 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		track    player.Metadata
+		expected float64
+	}{
+		{
+			"all metadata",
+			player.Metadata{RawTitle: "Song", RawArtist: "Artist", Album: "Album", Length: 3 * time.Minute},
+			totalWeight,
+		},
+		{
+			"wrong album",
+			player.Metadata{RawTitle: "Song", RawArtist: "Artist", Album: "Other", Length: 3 * time.Minute},
+			totalWeight - albumWeight,
+		},
+		{
+			"without album",
+			player.Metadata{RawTitle: "Song", RawArtist: "Artist", Length: 3 * time.Minute},
+			totalWeight,
+		},
+		{
+			"without album and length",
+			player.Metadata{RawTitle: "Song", RawArtist: "Artist"},
+			totalWeight,
+		},
+		{
+			"wrong title without album and length",
+			player.Metadata{RawTitle: "Other", RawArtist: "Artist"},
+			totalWeight / 3.0,
+		},
+		{
+			"title only",
+			player.Metadata{RawTitle: "Song"},
+			titleWeight * maxScale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(&tt.track, result); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Score() = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScoreTitleOnlyMismatch(t *testing.T) {
+	// a similar but different title
+	track := &player.Metadata{RawTitle: "Believer"}
+	result := LyricsResult{Title: "Believe", Artist: "Artist", Album: "Album", Duration: 3 * time.Minute}
+
+	if got := Score(track, result); got >= MinimumScore {
+		t.Errorf("Score() = %v; want below MinimumScore %v", got, MinimumScore)
+	}
+}
+
+func TestDurationScore(t *testing.T) {
+	if got := DurationScore(&player.Metadata{}, time.Minute); got != UnknownDurationScore {
+		t.Errorf("DurationScore() without length = %v; want %v", got, UnknownDurationScore)
+	}
+	track := &player.Metadata{Length: time.Minute}
+	if got := DurationScore(track, time.Minute); got != 1 {
+		t.Errorf("DurationScore() = %v; want 1", got)
+	}
+}
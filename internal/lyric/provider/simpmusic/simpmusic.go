@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Nadim147c/waybar-lyric/internal/config"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/formats/lrc"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/provider"
@@ -40,6 +41,8 @@ type data struct {
 	ContributorEmail string  `json:"contributorEmail"`
 }
 
+var client = provider.NewClient(config.ProviderSimpMusic)
+
 // Provider is the lrclib lyrics provider.
 var Provider = provider.NewProvider("simpmusic lyrics",
 	func(ctx context.Context, metadata *player.Metadata) (models.Lyrics, error) {
@@ -58,7 +61,6 @@ var Provider = provider.NewProvider("simpmusic lyrics",
 
 		slog.Info("Fetching lyrics from simpmusic api", "url", req.URL.String())
 
-		client := http.Client{}
 		resp, err := client.Do(req)
 		if err != nil {
 			return models.Lyrics{}, err
@@ -3,43 +3,106 @@ package youlyplus
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/Nadim147c/waybar-lyric/internal/config"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/formats/ttml"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/provider"
-	"github.com/Nadim147c/waybar-lyric/internal/match"
 	"github.com/Nadim147c/waybar-lyric/internal/player"
 )
 
-var Hosts = []string{
-	"https://lyricsplus.binimum.org/",
-	"https://lyricsplus.prjktla.my.id/",
-	"https://lyricsplus.prjktla.workers.dev/",
-	"https://lyricsplus.atomix.one/",
-	"https://lyricsplus-seven.vercel.app/",
-}
+var (
+	clients = provider.NewClients(config.ProviderYoulyPlus)
+	health  = provider.NewHealth(config.ProviderYoulyPlus)
+)
 
-// Provider is the youlyplus lyrics provider.
+// Provider is the youlyplus lyrics provider. The mirrors in
+// config.FetchOptions.YoulyPlusURLs are queried in order of health,
+// config.FetchOptions.YoulyPlusRace mirrors at once, until a mirror returns
+// lyrics or lyrics are not found.
 var Provider = &provider.LyricProvider{
 	Name: "youlyplus",
 	Fetch: func(ctx context.Context, wg *sync.WaitGroup, metadata *player.Metadata, out chan<- provider.Result) {
 		defer wg.Done()
-		for _, host := range Hosts {
-			wg.Go(func() {
-				var res provider.Result
-				res.Provider = fmt.Sprintf("youlyplus [%s]", host)
-				res.Lyrics, res.Err = genericProvider(ctx, host, metadata)
+
+		opts := config.Fetch()
+		hosts := health.Order(opts.YoulyPlusURLs, time.Now())
+		if len(hosts) == 0 {
+			out <- failed(errors.New("no youlyplus host"))
+			return
+		}
+
+		size := opts.YoulyPlusRace
+		if size <= 0 || size > len(hosts) {
+			size = len(hosts)
+		}
+
+		var errs []error
+		for batch := range slices.Chunk(hosts, size) {
+			res, ok := race(ctx, batch, metadata)
+			if ok {
 				out <- res
-			})
+				return
+			}
+			errs = append(errs, res.Err)
+			if ctx.Err() != nil || errors.Is(res.Err, models.ErrLyricsNotFound) {
+				break
+			}
 		}
+		out <- failed(errors.Join(errs...))
 	},
 }
 
+// race queries the hosts at once and returns the first lyrics. Other requests
+// are canceled when a host returns lyrics. It returns false if all hosts fail.
+func race(ctx context.Context, hosts []string, metadata *player.Metadata) (provider.Result, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan provider.Result, len(hosts))
+	for _, host := range hosts {
+		go func() {
+			var res provider.Result
+			res.Provider = fmt.Sprintf("youlyplus [%s]", host)
+
+			start := time.Now()
+			res.Lyrics, res.Err = genericProvider(ctx, host, metadata)
+			// requests canceled by the winner or the caller are not failures
+			if ctx.Err() == nil {
+				health.Record(host, time.Since(start), res.Err, time.Now())
+			}
+
+			results <- res
+		}()
+	}
+
+	var errs []error
+	for range hosts {
+		res := <-results
+		if res.Err == nil {
+			return res, true
+		}
+		errs = append(errs, res.Err)
+	}
+	return failed(errors.Join(errs...)), false
+}
+
+// failed returns the result of the provider with err.
+func failed(err error) provider.Result {
+	var res provider.Result
+	res.Provider = "youlyplus"
+	res.Err = err
+	return res
+}
+
 func genericProvider(ctx context.Context, host string, metadata *player.Metadata) (models.Lyrics, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host, nil)
 	if err != nil {
@@ -49,14 +112,15 @@ func genericProvider(ctx context.Context, host string, metadata *player.Metadata
 	params := url.Values{}
 	params.Set("title", metadata.RawTitle)
 	params.Set("artist", metadata.Artist)
-	params.Set("album", metadata.Album)
+	if metadata.Album != "" {
+		params.Set("album", metadata.Album)
+	}
 	req.URL.RawQuery = params.Encode()
 	req.URL.Path = "/v1/ttml/get"
 
 	slog.Info("Fetching lyrics from youlyplus api", "url", req.URL.String())
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	resp, err := clients.Get(host).Do(req)
 	if err != nil {
 		return models.Lyrics{}, err
 	}
@@ -84,7 +148,7 @@ func genericProvider(ctx context.Context, host string, metadata *player.Metadata
 		return models.Lyrics{}, err
 	}
 
-	score := match.Durations(metadata.Length, dur)
+	score := provider.DurationScore(metadata, dur)
 
 	lines, err := ttml.ParseText(data.TTML)
 	if err != nil {
@@ -0,0 +1,48 @@
+package match
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Pattern matches whole strings with a case-insensitive glob (e.g. firefox*) or
+// a regular expression enclosed in slashes (e.g. /^chrom(e|ium)$/). The zero
+// Pattern matches any string.
+type Pattern struct {
+	re *regexp.Regexp
+}
+
+// CompilePattern compiles a glob or /regex/ pattern.
+func CompilePattern(pattern string) (Pattern, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		return Pattern{re: re}, err
+	}
+
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	return Pattern{re: re}, err
+}
+
+// Match reports whether the pattern matches s.
+func (p Pattern) Match(s string) bool {
+	return p.re == nil || p.re.MatchString(s)
+}
+
+// IsZero reports whether the pattern matches any string.
+func (p Pattern) IsZero() bool {
+	return p.re == nil
+}
@@ -0,0 +1,38 @@
+package match
+
+import "testing"
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		input    string
+		expected bool
+	}{
+		{"firefox", "firefox", true},
+		{"firefox", "Firefox", true},
+		{"firefox", "firefox.instance_1_24", false},
+		{"firefox*", "firefox.instance_1_24", true},
+		{"*.instance_1_?", "chromium.instance_1_2", true},
+		{"music.youtube.com", "music-youtube.com", false},
+		{"*.youtube.com", "music.youtube.com", true},
+		{"/^chrom(e|ium)$/", "chromium", true},
+		{"/^chrom(e|ium)$/", "Chromium", false},
+		{"/kdeconnect/", "kdeconnect.mpris_abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.input, func(t *testing.T) {
+			p, err := CompilePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("CompilePattern(%q) failed: %v", tt.pattern, err)
+			}
+			if got := p.Match(tt.input); got != tt.expected {
+				t.Errorf("CompilePattern(%q).Match(%q) = %v; want %v", tt.pattern, tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := CompilePattern("/(/"); err == nil {
+		t.Errorf("CompilePattern(%q) = nil error; want error", "/(/")
+	}
+}
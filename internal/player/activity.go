@@ -0,0 +1,183 @@
+package player
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/gofrs/flock"
+)
+
+// ActivityFileName is the name of the file where the activity is shared with
+// other instances and one-shot commands, which do not receive player signals.
+const ActivityFileName = "waybar-lyric-activity.json"
+
+// activityMaxAge is the age of activity which is removed from the file.
+const activityMaxAge = 24 * time.Hour
+
+// activityCheckInterval is the minimum interval between checking the activity
+// file for changes by other instances.
+const activityCheckInterval = time.Second
+
+// activity is the time of last PlaybackStatus change of players keyed by their
+// unique bus name.
+var activity = struct {
+	sync.Mutex
+	changed map[string]time.Time
+	checked time.Time // when the file was checked for changes
+	modTime time.Time // modification time of the file when it was read
+
+	// pending is the changes not written to the file yet. Zero time means the
+	// player is forgotten.
+	pending map[string]time.Time
+	writing bool
+	writes  sync.WaitGroup // running writer, see writeActivity
+}{changed: map[string]time.Time{}, pending: map[string]time.Time{}}
+
+// activityPath returns the path of the activity file. Unique bus names are
+// only valid in the session, so it is kept in the runtime directory.
+func activityPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, ActivityFileName)
+}
+
+// recordActivity records the PlaybackStatus change of player with unique bus
+// name owner.
+func recordActivity(owner string, now time.Time) {
+	activity.Lock()
+	defer activity.Unlock()
+	activity.changed[owner] = now
+	queueActivity(owner, now)
+}
+
+// forgetActivity forgets the player with unique bus name owner.
+func forgetActivity(owner string) {
+	activity.Lock()
+	defer activity.Unlock()
+	delete(activity.changed, owner)
+	queueActivity(owner, time.Time{})
+}
+
+// queueActivity queues the change of owner to be written to the activity file
+// in background, so signals are not blocked by the file lock. activity must be
+// locked.
+func queueActivity(owner string, t time.Time) {
+	activity.pending[owner] = t
+	if !activity.writing {
+		activity.writing = true
+		activity.writes.Go(writeActivity)
+	}
+}
+
+// writeActivity writes the pending changes to the activity file until there
+// is no pending change.
+func writeActivity() {
+	for {
+		activity.Lock()
+		pending := activity.pending
+		activity.pending = map[string]time.Time{}
+		activity.writing = len(pending) != 0
+		activity.Unlock()
+
+		if len(pending) == 0 {
+			return
+		}
+		updateActivityFile(func(changed map[string]time.Time) {
+			for owner, t := range pending {
+				if t.IsZero() {
+					delete(changed, owner)
+				} else {
+					changed[owner] = t
+				}
+			}
+		})
+	}
+}
+
+// lastChanged returns the time of last PlaybackStatus change of player with
+// unique bus name owner. Changes seen by other instances are read from the
+// activity file when it is modified. It returns zero time if the change was
+// not seen.
+func lastChanged(owner string) time.Time {
+	activity.Lock()
+	defer activity.Unlock()
+
+	if time.Since(activity.checked) >= activityCheckInterval {
+		activity.checked = time.Now()
+		info, err := os.Stat(activityPath())
+		if err == nil && !info.ModTime().Equal(activity.modTime) {
+			activity.modTime = info.ModTime()
+			for name, t := range readActivityFile() {
+				if t.After(activity.changed[name]) {
+					activity.changed[name] = t
+				}
+			}
+		}
+	}
+	return activity.changed[owner]
+}
+
+// readActivityFile reads the activity file. Missing or invalid file is empty.
+func readActivityFile() map[string]time.Time {
+	changed := map[string]time.Time{}
+	b, err := os.ReadFile(activityPath())
+	if err != nil {
+		return changed
+	}
+	if err := json.Unmarshal(b, &changed); err != nil {
+		slog.Debug("Invalid player activity file", "error", err)
+		return map[string]time.Time{}
+	}
+	return changed
+}
+
+// updateActivityFile applies update to the activity file under a file lock,
+// so activity recorded by other instances is kept.
+func updateActivityFile(update func(changed map[string]time.Time)) {
+	path := activityPath()
+
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		slog.Debug("Failed to lock player activity file", "error", err)
+		return
+	}
+	defer lock.Close()
+
+	changed := readActivityFile()
+	update(changed)
+	for name, t := range changed {
+		if time.Since(t) > activityMaxAge {
+			delete(changed, name)
+		}
+	}
+
+	b, err := json.Marshal(changed)
+	if err != nil {
+		return
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		slog.Debug("Failed to write player activity file", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Debug("Failed to write player activity file", "error", err)
+	}
+}
+
+// nameOwner returns the unique bus name of the owner of name.
+func nameOwner(conn *dbus.Conn, name string) (string, error) {
+	var owner string
+	err := conn.BusObject().
+		Call("org.freedesktop.DBus.GetNameOwner", 0, name).
+		Store(&owner)
+	return owner, err
+}
@@ -0,0 +1,84 @@
+package player
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// resetActivity waits for the activity to be written and forgets the activity
+// in memory, like a new process.
+func resetActivity() {
+	activity.writes.Wait()
+	activity.Lock()
+	defer activity.Unlock()
+	activity.checked, activity.modTime = time.Time{}, time.Time{}
+	activity.changed = map[string]time.Time{}
+}
+
+func TestActivityShared(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(resetActivity)
+
+	now := time.Now().Truncate(time.Second)
+	old := now.Add(-2 * activityMaxAge)
+
+	resetActivity()
+	recordActivity(":1.10", now)
+	recordActivity(":1.11", now.Add(-time.Minute))
+	recordActivity(":1.12", old)
+	forgetActivity(":1.11")
+
+	// a one-shot command reads the activity recorded by the main loop
+	resetActivity()
+
+	tests := []struct {
+		owner    string
+		expected time.Time
+	}{
+		{":1.10", now},
+		{":1.11", time.Time{}}, // forgotten
+		{":1.12", time.Time{}}, // too old
+		{":1.13", time.Time{}}, // never seen
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			if got := lastChanged(tt.owner); !got.Equal(tt.expected) {
+				t.Errorf("lastChanged(%q) = %v; want %v", tt.owner, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestActivityReloaded(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(resetActivity)
+
+	now := time.Now().Truncate(time.Second)
+
+	resetActivity()
+	recordActivity(":1.20", now)
+	activity.writes.Wait()
+	if got := lastChanged(":1.21"); !got.IsZero() {
+		t.Fatalf("lastChanged(%q) = %v; want zero time", ":1.21", got)
+	}
+
+	// another instance records activity after the file was read
+	updateActivityFile(func(changed map[string]time.Time) { changed[":1.21"] = now })
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(activityPath(), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lastChanged(":1.21"); !got.IsZero() {
+		t.Errorf("lastChanged(%q) = %v within check interval; want zero time", ":1.21", got)
+	}
+
+	activity.Lock()
+	activity.checked = time.Time{}
+	activity.Unlock()
+	if got := lastChanged(":1.21"); !got.Equal(now) {
+		t.Errorf("lastChanged(%q) = %v after the file changed; want %v", ":1.21", got, now)
+	}
+}
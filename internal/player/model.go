@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/Nadim147c/go-mpris"
@@ -87,6 +86,8 @@ type Metadata struct {
 	Position time.Duration `json:"position"`
 	Length   time.Duration `json:"length"`
 	Shuffle  bool          `json:"shuffle"`
+	// Rate is the playback rate, 1 is the normal speed.
+	Rate float64 `json:"rate"`
 
 	Status mpris.PlaybackStatus `json:"status"`
 }
@@ -99,22 +100,37 @@ func (p *Metadata) Percentage() int {
 	return int(((p.Position * 100) / p.Length))
 }
 
+// rate returns the playback rate. Unknown rate is the normal speed.
+func (p *Metadata) rate() float64 {
+	if p.Rate <= 0 {
+		return 1
+	}
+	return p.Rate
+}
+
+// Elapsed returns the duration of the track played in d at playback rate.
+func (p *Metadata) Elapsed(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * p.rate())
+}
+
+// Remaining returns the duration it takes to play d of the track at playback
+// rate.
+func (p *Metadata) Remaining(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / p.rate())
+}
+
 // UpdatePosition updates the position of player.
 func (p *Metadata) UpdatePosition(player *mpris.Player) error {
 	pos, err := player.GetPosition()
 	if err != nil {
 		return err
 	}
-	p.Position = pos
 
-	// HACK: YoutubeMusic dbus position is rounded to seconds which isn't ideal
-	// for realtime lyrics. Add 1.1sec delay make lyrics always appear before
-	// the song.
-	if player.GetName() == mpris.BaseInterface+".YoutubeMusic" ||
-		(p.URL.IsNil() && strings.Contains(p.URL.Host, "music.youtube.com")) {
-		slog.Debug("Adding 1.1 second to adjust mpris delay")
-		p.Position += 1100 * time.Millisecond
+	if offset := positionOffset(player.GetName(), p.URL); offset != 0 {
+		slog.Debug("Adding player position offset", "offset", offset)
+		pos += offset
 	}
 
+	p.Position = pos
 	return nil
 }
@@ -3,9 +3,11 @@ package player
 import (
 	"errors"
 	"log/slog"
+	"net/url"
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/Nadim147c/go-mpris"
 	"github.com/Nadim147c/waybar-lyric/internal/config"
@@ -27,8 +29,66 @@ var (
 	ErrNoPlayer = errors.New("no preferred player")
 )
 
-// Select selects correct parses for player.
+// Select selects the player according to config.SelectStrategy. Players
+// without the metadata required by config.RequireMeta are skipped.
 func Select(conn *dbus.Conn) (*mpris.Player, error) {
+	return SelectSlot(conn, 0)
+}
+
+// SelectSlot selects the player in the slot. Slot 0 is the player returned by
+// Select. Slot N is the Nth eligible player in config.PlayerPatterns order,
+// then bus name order, so players keep their slots regardless of
+// config.SelectStrategy.
+func SelectSlot(conn *dbus.Conn, slot int) (*mpris.Player, error) {
+	players, err := playerNames(conn)
+	if err != nil {
+		return nil, err
+	}
+	if slot == 0 {
+		players = bySelectStrategy(conn, players)
+		slot = 1
+	}
+
+	for _, playerName := range players {
+		slog.Debug("Checking player metadata", "for", playerName)
+		player := mpris.New(conn, playerName)
+		if !hasRequiredMetadata(player) {
+			continue
+		}
+		slot--
+		if slot == 0 {
+			return player, nil
+		}
+	}
+
+	return nil, ErrNoPlayer
+}
+
+// SelectAll returns every eligible player ordered by config.SelectStrategy.
+// The first player is the player returned by Select.
+func SelectAll(conn *dbus.Conn) ([]*mpris.Player, error) {
+	names, err := playerNames(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var players []*mpris.Player
+	for _, playerName := range bySelectStrategy(conn, names) {
+		player := mpris.New(conn, playerName)
+		if hasRequiredMetadata(player) {
+			players = append(players, player)
+		}
+	}
+
+	if len(players) == 0 {
+		return nil, ErrNoPlayer
+	}
+	return players, nil
+}
+
+// playerNames returns the names of the players which are not ignored, in
+// config.PlayerPatterns order then bus name order.
+func playerNames(conn *dbus.Conn) ([]string, error) {
 	players, err := mpris.List(conn)
 	if err != nil {
 		return nil, err
@@ -39,33 +99,131 @@ func Select(conn *dbus.Conn) (*mpris.Player, error) {
 		return nil, ErrNoPlayer
 	}
 
-	if len(config.PlayerList) != 0 {
-		stripped := make([]string, len(players))
-		for i, p := range players {
-			stripped[i] = stripPlayerName(p)
+	slices.Sort(players)
+	return prioritize(players, playerHosts(conn, players)), nil
+}
+
+// bySelectStrategy orders the players by config.SelectStrategy.
+func bySelectStrategy(conn *dbus.Conn, players []string) []string {
+	switch config.SelectStrategy {
+	case config.SelectActive:
+		return byActivity(conn, players, true)
+	case config.SelectLastChanged:
+		return byActivity(conn, players, false)
+	}
+	return players
+}
+
+// playerHosts returns the URL host of the players if any player pattern
+// matches the host.
+func playerHosts(conn *dbus.Conn, players []string) map[string]string {
+	needsHost := slices.ContainsFunc(config.PlayerPatterns, config.PlayerPattern.NeedsHost) ||
+		slices.ContainsFunc(config.IgnorePatterns, config.PlayerPattern.NeedsHost)
+	if !needsHost {
+		return nil
+	}
+
+	hosts := make(map[string]string, len(players))
+	for _, name := range players {
+		urlStr, err := mpris.New(conn, name).GetURL()
+		if err != nil || urlStr == "" {
+			continue
+		}
+		if u, err := url.Parse(urlStr); err == nil {
+			hosts[name] = u.Hostname()
+		}
+	}
+	return hosts
+}
+
+// matchNames returns the names of the player matched by player patterns.
+func matchNames(name string) []string {
+	if len(name) <= PrefixSize {
+		return []string{name}
+	}
+	return []string{name, name[PrefixSize:], StripName(name)}
+}
+
+// prioritize returns the players in config.PlayerPatterns order followed by
+// the rest of the players. Players matched by config.IgnorePatterns are
+// removed.
+func prioritize(players []string, hosts map[string]string) []string {
+	matches := func(p config.PlayerPattern, name string) bool {
+		return p.Match(matchNames(name), hosts[name])
+	}
+
+	allowed := make([]string, 0, len(players))
+	for _, name := range players {
+		ignored := slices.ContainsFunc(config.IgnorePatterns, func(p config.PlayerPattern) bool {
+			return matches(p, name)
+		})
+		if ignored {
+			slog.Debug("Ignoring player", "player", name, "host", hosts[name])
+			continue
 		}
+		allowed = append(allowed, name)
+	}
 
-		for _, p := range config.PlayerList {
-			idx := slices.Index(stripped, p)
-			if idx < 0 {
-				continue
-			}
-			player := mpris.New(conn, players[idx])
-			if hasAlbumAndArtists(player) {
-				return player, nil
+	ordered := make([]string, 0, len(allowed))
+	for _, p := range config.PlayerPatterns {
+		for _, name := range allowed {
+			if matches(p, name) && !slices.Contains(ordered, name) {
+				ordered = append(ordered, name)
 			}
 		}
 	}
+	for _, name := range allowed {
+		if !slices.Contains(ordered, name) {
+			ordered = append(ordered, name)
+		}
+	}
+	return ordered
+}
 
-	for _, playerName := range players {
-		slog.Debug("Checking player url", "for", playerName)
-		player := mpris.New(conn, playerName)
-		if hasAlbumAndArtists(player) {
-			return player, nil
+// candidate is a player ordered by its activity.
+type candidate struct {
+	name    string
+	playing bool
+	changed time.Time
+}
+
+// byActivity orders the players by the last PlaybackStatus change. Playing
+// players are ordered first if preferPlaying is true. Priority order is kept
+// for players with same activity.
+func byActivity(conn *dbus.Conn, players []string, preferPlaying bool) []string {
+	candidates := make([]candidate, len(players))
+	for i, name := range players {
+		candidates[i] = candidate{name: name, playing: false, changed: time.Time{}}
+		if owner, err := nameOwner(conn, name); err == nil {
+			candidates[i].changed = lastChanged(owner)
+		}
+		if preferPlaying {
+			status, err := mpris.New(conn, name).GetPlaybackStatus()
+			candidates[i].playing = err == nil && status == mpris.PlaybackPlaying
 		}
 	}
 
-	return nil, ErrNoPlayer
+	sortCandidates(candidates)
+
+	ordered := make([]string, len(candidates))
+	for i, c := range candidates {
+		ordered[i] = c.name
+	}
+	return ordered
+}
+
+// sortCandidates sorts playing candidates first, then by the most recent
+// change.
+func sortCandidates(candidates []candidate) {
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.playing != b.playing {
+			if a.playing {
+				return -1
+			}
+			return 1
+		}
+		return b.changed.Compare(a.changed)
+	})
 }
 
 func should[T any](v T, _ error) T { return v }
@@ -118,9 +276,10 @@ func Parse(player *mpris.Player) (*Metadata, error) {
 	shuffle := should(player.GetShuffle())
 	cover := should(player.GetArtURL())
 	volume := should(player.GetVolume())
+	rate := should(player.GetRate())
 
 	album, err := player.GetAlbum()
-	if err != nil {
+	if err != nil && config.Requires(config.MetadataAlbum) {
 		return nil, err
 	}
 
@@ -132,24 +291,23 @@ func Parse(player *mpris.Player) (*Metadata, error) {
 		return nil, err
 	}
 
-	length, err := player.GetLength()
-	if err != nil {
-		return nil, err
-	}
-	if length == 0 {
-		return nil, ErrNoLength
-	}
-
-	artistList, err := player.GetArtist()
-	if err != nil {
-		return nil, err
+	length := should(player.GetLength())
+	if length <= 0 {
+		if config.Requires(config.MetadataLength) {
+			return nil, ErrNoLength
+		}
+		length = 0
 	}
 
-	if len(artistList) == 0 {
+	artistList := slices.DeleteFunc(should(player.GetArtist()), func(a string) bool { return a == "" })
+	if len(artistList) == 0 && config.Requires(config.MetadataArtist) {
 		return nil, ErrNoArtists
 	}
 
-	artist := artistList[0]
+	var artist string
+	if len(artistList) != 0 {
+		artist = artistList[0]
+	}
 
 	title, err := player.GetTitle()
 	if err != nil {
@@ -157,7 +315,7 @@ func Parse(player *mpris.Player) (*Metadata, error) {
 	}
 
 	if title == "" {
-		return nil, ErrNoArtists
+		return nil, ErrNoTitle
 	}
 
 	trackid := should(player.GetTrackID())
@@ -178,6 +336,7 @@ func Parse(player *mpris.Player) (*Metadata, error) {
 		Status:    status,
 		URL:       trackURL,
 		Volume:    volume,
+		Rate:      rate,
 		Position:  0, // will be updated by UpdatePosition
 	}
 
@@ -187,10 +346,13 @@ func Parse(player *mpris.Player) (*Metadata, error) {
 	return metadata, err
 }
 
-func stripPlayerName(n string) string {
+// StripName strips the MPRIS interface prefix and instance suffix from the
+// player bus name (e.g. org.mpris.MediaPlayer2.firefox.instance_1_24 ->
+// firefox).
+func StripName(n string) string {
+	n = strings.TrimPrefix(n, mpris.BaseInterface+".")
 	if idx := strings.Index(n, ".instance"); idx > 0 {
-		return n[PrefixSize:idx]
-	} else {
-		return n[PrefixSize:]
+		return n[:idx]
 	}
+	return n
 }
@@ -0,0 +1,184 @@
+package player
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+func TestPrioritize(t *testing.T) {
+	players := []string{
+		"org.mpris.MediaPlayer2.mpv",
+		"org.mpris.MediaPlayer2.firefox.instance_1_2",
+		"org.mpris.MediaPlayer2.firefox.instance_1_3",
+		"org.mpris.MediaPlayer2.kdeconnect.mpris_000001",
+		"org.mpris.MediaPlayer2.spotify",
+	}
+	hosts := map[string]string{
+		"org.mpris.MediaPlayer2.firefox.instance_1_2": "www.youtube.com",
+		"org.mpris.MediaPlayer2.firefox.instance_1_3": "music.youtube.com",
+	}
+
+	tests := []struct {
+		name     string
+		players  []string
+		ignored  []string
+		expected []string
+	}{
+		{
+			"no patterns",
+			nil,
+			nil,
+			players,
+		},
+		{
+			"exact names",
+			[]string{"spotify", "firefox"},
+			nil,
+			[]string{
+				"org.mpris.MediaPlayer2.spotify",
+				"org.mpris.MediaPlayer2.firefox.instance_1_2",
+				"org.mpris.MediaPlayer2.firefox.instance_1_3",
+				"org.mpris.MediaPlayer2.mpv",
+				"org.mpris.MediaPlayer2.kdeconnect.mpris_000001",
+			},
+		},
+		{
+			"ignore and host",
+			[]string{"firefox@music.youtube.com"},
+			[]string{"kdeconnect*", "@www.youtube.com"},
+			[]string{
+				"org.mpris.MediaPlayer2.firefox.instance_1_3",
+				"org.mpris.MediaPlayer2.mpv",
+				"org.mpris.MediaPlayer2.spotify",
+			},
+		},
+		{
+			"regex and instance",
+			[]string{"/^(spotify|mpv)$/", "firefox.instance_1_3"},
+			[]string{"/^kde/"},
+			[]string{
+				"org.mpris.MediaPlayer2.mpv",
+				"org.mpris.MediaPlayer2.spotify",
+				"org.mpris.MediaPlayer2.firefox.instance_1_3",
+				"org.mpris.MediaPlayer2.firefox.instance_1_2",
+			},
+		},
+	}
+
+	list, ignored := config.PlayerList, config.IgnorePlayers
+	t.Cleanup(func() {
+		config.PlayerList, config.IgnorePlayers = list, ignored
+		if err := config.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.PlayerList, config.IgnorePlayers = tt.players, tt.ignored
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := prioritize(players, hosts); !slices.Equal(got, tt.expected) {
+				t.Errorf("prioritize(%q) = %q; want %q", players, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortCandidates(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		candidates []candidate
+		expected   []string
+	}{
+		{
+			"playing first",
+			[]candidate{
+				{"firefox", false, now},
+				{"spotify", true, now.Add(-time.Minute)},
+			},
+			[]string{"spotify", "firefox"},
+		},
+		{
+			"most recently started",
+			[]candidate{
+				{"firefox", true, now.Add(-time.Minute)},
+				{"spotify", true, now},
+			},
+			[]string{"spotify", "firefox"},
+		},
+		{
+			"priority without activity",
+			[]candidate{
+				{"firefox", false, time.Time{}},
+				{"spotify", false, time.Time{}},
+				{"mpv", false, now},
+			},
+			[]string{"mpv", "firefox", "spotify"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortCandidates(tt.candidates)
+			got := make([]string, len(tt.candidates))
+			for i, c := range tt.candidates {
+				got[i] = c.name
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("sortCandidates() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// fakeMetadata implements metadataGetter.
+type fakeMetadata struct {
+	title, album string
+	artists      []string
+	length       time.Duration
+}
+
+func (f fakeMetadata) GetTitle() (string, error)         { return f.title, nil }
+func (f fakeMetadata) GetAlbum() (string, error)         { return f.album, nil }
+func (f fakeMetadata) GetArtist() ([]string, error)      { return f.artists, nil }
+func (f fakeMetadata) GetLength() (time.Duration, error) { return f.length, nil }
+
+func TestHasRequiredMetadata(t *testing.T) {
+	full := fakeMetadata{"title", "album", []string{"artist"}, time.Minute}
+	stream := fakeMetadata{"title", "", []string{""}, 0}
+
+	tests := []struct {
+		name     string
+		require  []string
+		metadata fakeMetadata
+		expected bool
+	}{
+		{"all metadata", []string{"album", "artist", "length"}, full, true},
+		{"missing album", []string{"album", "artist", "length"}, stream, false},
+		{"artist only", []string{"artist"}, fakeMetadata{"title", "", []string{"", "artist"}, 0}, true},
+		{"empty artist", []string{"artist"}, stream, false},
+		{"length only", []string{"length"}, stream, false},
+		{"nothing required", nil, stream, true},
+		{"missing title", nil, fakeMetadata{"", "album", []string{"artist"}, time.Minute}, false},
+	}
+
+	required := config.RequireMeta
+	t.Cleanup(func() { config.RequireMeta = required })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.RequireMeta = tt.require
+			if got := hasRequiredMetadata(tt.metadata); got != tt.expected {
+				t.Errorf("hasRequiredMetadata(%+v) with %q = %v; want %v", tt.metadata, tt.require, got, tt.expected)
+			}
+		})
+	}
+}
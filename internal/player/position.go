@@ -0,0 +1,33 @@
+package player
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+// positionOffset returns the position offset of the player matched by stripped
+// bus name or URL host from config.PositionOffsets.
+func positionOffset(name string, u *URL) time.Duration {
+	stripped := StripName(name)
+	var host string
+	if !u.IsNil() {
+		host = u.Hostname()
+	}
+
+	for key, offset := range config.PositionOffsets {
+		if strings.EqualFold(key, stripped) {
+			return offset
+		}
+	}
+	if host == "" {
+		return 0
+	}
+	for key, offset := range config.PositionOffsets {
+		if host == key || strings.HasSuffix(host, "."+key) {
+			return offset
+		}
+	}
+	return 0
+}
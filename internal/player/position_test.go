@@ -0,0 +1,40 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+func TestPositionOffset(t *testing.T) {
+	config.PositionOffsets = map[string]time.Duration{
+		"YoutubeMusic":      time.Second,
+		"music.youtube.com": 2 * time.Second,
+	}
+	t.Cleanup(func() { config.PositionOffsets = map[string]time.Duration{} })
+
+	tests := []struct {
+		name     string
+		url      string
+		expected time.Duration
+	}{
+		{"org.mpris.MediaPlayer2.YoutubeMusic", "", time.Second},
+		{"org.mpris.MediaPlayer2.youtubemusic.instance_1", "", time.Second},
+		{"org.mpris.MediaPlayer2.firefox", "https://music.youtube.com/watch?v=id", 2 * time.Second},
+		{"org.mpris.MediaPlayer2.firefox", "https://www.youtube.com/watch?v=id", 0},
+		{"org.mpris.MediaPlayer2.spotify", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.url, func(t *testing.T) {
+			var u *URL
+			if tt.url != "" {
+				u, _ = NewURL(tt.url)
+			}
+			if offset := positionOffset(tt.name, u); offset != tt.expected {
+				t.Errorf("positionOffset(%q, %q) = %v; want %v", tt.name, tt.url, offset, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package player
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/go-mpris"
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/dbustest"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// fakePlayer exports a MPRIS player with the name on the bus and returns its
+// properties.
+func fakePlayer(t *testing.T, address, name string, status mpris.PlaybackStatus) *prop.Properties {
+	t.Helper()
+
+	conn := dbustest.Connect(t, address)
+	metadata := map[string]dbus.Variant{
+		"xesam:title":  dbus.MakeVariant(name),
+		"xesam:album":  dbus.MakeVariant("album"),
+		"xesam:artist": dbus.MakeVariant([]string{"artist"}),
+		"mpris:length": dbus.MakeVariant(int64(time.Minute / time.Microsecond)),
+	}
+	props, err := prop.Export(conn, "/org/mpris/MediaPlayer2", prop.Map{
+		mpris.PlayerInterface: {
+			"Metadata":       {Value: metadata},
+			"PlaybackStatus": {Value: string(status), Emit: prop.EmitTrue},
+			"Position":       {Value: int64(0)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.RequestName(mpris.BaseInterface+"."+name, dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatal(err)
+	}
+	return props
+}
+
+func TestSelectSlot(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(resetActivity)
+
+	strategy := config.SelectStrategy
+	config.SelectStrategy = config.SelectActive
+	t.Cleanup(func() { config.SelectStrategy = strategy })
+
+	address := dbustest.Bus(t)
+	spotify := fakePlayer(t, address, "spotify", mpris.PlaybackPaused)
+	firefox := fakePlayer(t, address, "firefox", mpris.PlaybackPaused)
+	conn := dbustest.Connect(t, address)
+
+	names := func(slots ...int) []string {
+		t.Helper()
+		got := make([]string, len(slots))
+		for i, slot := range slots {
+			p, err := SelectSlot(conn, slot)
+			if err != nil {
+				t.Fatalf("SelectSlot(%d) = %v", slot, err)
+			}
+			got[i] = StripName(p.GetName())
+		}
+		return got
+	}
+
+	tests := []struct {
+		name    string
+		playing *prop.Properties
+		want    []string
+	}{
+		{"spotify playing", spotify, []string{"spotify", "firefox", "spotify"}},
+		{"firefox playing", firefox, []string{"firefox", "firefox", "spotify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range []*prop.Properties{spotify, firefox} {
+				status := mpris.PlaybackPaused
+				if p == tt.playing {
+					status = mpris.PlaybackPlaying
+				}
+				p.SetMust(mpris.PlayerInterface, "PlaybackStatus", string(status))
+			}
+
+			if got := names(0, 1, 2); !slices.Equal(got, tt.want) {
+				t.Errorf("SelectSlot(0, 1, 2) = %q; want %q", got, tt.want)
+			}
+
+			all, err := SelectAll(conn)
+			if err != nil {
+				t.Fatalf("SelectAll() = %v", err)
+			}
+			if len(all) != 2 || StripName(all[0].GetName()) != tt.want[0] {
+				t.Errorf("SelectAll()[0] = %s; want %s", all[0].GetName(), tt.want[0])
+			}
+		})
+	}
+
+	if _, err := SelectSlot(conn, 3); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("SelectSlot(3) = %v; want %v", err, ErrNoPlayer)
+	}
+}
+
+func TestTrackerCombine(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Cleanup(resetActivity)
+
+	strategy, combine := config.SelectStrategy, config.Combine
+	config.SelectStrategy, config.Combine = config.SelectActive, true
+	t.Cleanup(func() { config.SelectStrategy, config.Combine = strategy, combine })
+
+	address := dbustest.Bus(t)
+	fakePlayer(t, address, "spotify", mpris.PlaybackPaused)
+	fakePlayer(t, address, "firefox", mpris.PlaybackPlaying)
+
+	players, err := NewTracker(dbustest.Connect(t, address)).Players()
+	if err != nil {
+		t.Fatalf("Players() = %v", err)
+	}
+
+	got := make([]string, len(players))
+	for i, p := range players {
+		got[i] = p.Metadata.RawTitle
+	}
+	if want := []string{"firefox", "spotify"}; !slices.Equal(got, want) {
+		t.Errorf("Players() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,283 @@
+package player
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nadim147c/go-mpris"
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	seekedSignal           = mpris.PlayerInterface + ".Seeked"
+	nameOwnerChangedSignal = "org.freedesktop.DBus.NameOwnerChanged"
+)
+
+// Tracker tracks the selected player, or every eligible player if
+// config.Combine is set. The metadata is cached and only fetched again when a
+// player signals a change, and the position is extrapolated from the last known
+// position at playback rate.
+type Tracker struct {
+	conn *dbus.Conn
+
+	mu      sync.Mutex
+	players []*tracked // in config.SelectStrategy order
+	synced  time.Time  // when players were refreshed
+	dirty   bool
+}
+
+// tracked is a tracked player and its cached metadata.
+type tracked struct {
+	player *mpris.Player
+	owner  string // unique bus name of player, sender of its signals
+	meta   *Metadata
+	synced time.Time // when meta.Position was known
+	whole  int       // consecutive syncs at whole second positions
+}
+
+// Snapshot is a tracked player and a copy of its metadata with extrapolated
+// position.
+type Snapshot struct {
+	Player   *mpris.Player
+	Metadata *Metadata
+}
+
+// NewTracker creates a new Tracker for players on conn.
+func NewTracker(conn *dbus.Conn) *Tracker {
+	t := new(Tracker)
+	t.conn = conn
+	t.dirty = true
+	return t
+}
+
+// Subscribe subscribes to the MPRIS signals and players appearing or
+// disappearing on the bus. Signals are sent to ch and must be passed to Handle.
+func (t *Tracker) Subscribe(ch chan<- *dbus.Signal) error {
+	err := t.conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg0Namespace(mpris.BaseInterface),
+	)
+	if err != nil {
+		return err
+	}
+	return mpris.OnSignal(t.conn, ch)
+}
+
+// Handle updates the tracker from a signal. It returns true if the signal
+// changes the tracked player.
+func (t *Tracker) Handle(sig *dbus.Signal) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch sig.Name {
+	case nameOwnerChangedSignal:
+		if len(sig.Body) == 0 {
+			return false
+		}
+		name, ok := sig.Body[0].(string)
+		if !ok || !strings.HasPrefix(name, mpris.BaseInterface) {
+			return false
+		}
+		if len(sig.Body) == 3 {
+			if old, ok := sig.Body[1].(string); ok && old != "" {
+				forgetActivity(old)
+			}
+		}
+		slog.Debug("Player appeared or disappeared", "player", name)
+		t.dirty = true
+		return true
+	case mpris.PropertiesChangedSignal:
+		if len(sig.Body) == 0 {
+			return false
+		}
+		iface, ok := sig.Body[0].(string)
+		if !ok || iface != mpris.PlayerInterface {
+			return false
+		}
+		if len(sig.Body) > 1 {
+			changed, ok := sig.Body[1].(map[string]dbus.Variant)
+			if _, found := changed["PlaybackStatus"]; ok && found {
+				recordActivity(sig.Sender, time.Now())
+			}
+		}
+		// properties of other players may change the selected player
+		slog.Debug("Player properties changed", "sender", sig.Sender)
+		t.dirty = true
+		return true
+	case seekedSignal:
+		if len(sig.Body) == 0 {
+			return false
+		}
+		us, ok := sig.Body[0].(int64)
+		if !ok {
+			return false
+		}
+		for _, p := range t.players {
+			if p.meta == nil || sig.Sender != p.owner {
+				continue
+			}
+			pos := time.Duration(us) * time.Microsecond
+			p.seek(pos+positionOffset(p.player.GetName(), p.meta.URL), time.Now())
+			slog.Debug("Player seeked", "player", p.player.GetName(), "position", p.meta.Position)
+			return true
+		}
+	}
+
+	return false
+}
+
+// seek sets the known position of the player at time now.
+func (p *tracked) seek(pos time.Duration, now time.Time) {
+	p.meta.Position = pos
+	p.synced = now
+}
+
+// Metadata returns the selected player and a copy of its metadata with
+// extrapolated position. See Players.
+func (t *Tracker) Metadata() (*mpris.Player, *Metadata, error) {
+	players, err := t.Players()
+	if err != nil {
+		return nil, nil, err
+	}
+	return players[0].Player, players[0].Metadata, nil
+}
+
+// Players returns the player in config.PlayerSlot, or every eligible player
+// in config.SelectStrategy order if config.Combine is set. The players are
+// selected and parsed again only if a player has signaled a change or
+// config.SyncInterval has passed since last parse.
+func (t *Tracker) Players() ([]Snapshot, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.dirty || len(t.players) == 0 || now.Sub(t.synced) >= config.SyncInterval {
+		if err := t.refresh(); err != nil {
+			t.players = nil
+			t.dirty = true
+			return nil, err
+		}
+		t.dirty = false
+		now = t.synced
+	}
+
+	snapshots := make([]Snapshot, len(t.players))
+	for i, p := range t.players {
+		snapshots[i] = Snapshot{Player: p.player, Metadata: p.extrapolate(now)}
+	}
+	return snapshots, nil
+}
+
+// refresh selects the players and fetches their metadata. Players which were
+// tracked before keep their unique bus name.
+func (t *Tracker) refresh() error {
+	var players []*mpris.Player
+	if config.Combine {
+		all, err := SelectAll(t.conn)
+		if err != nil {
+			return err
+		}
+		players = all
+	} else {
+		p, err := SelectSlot(t.conn, config.PlayerSlot)
+		if err != nil {
+			return err
+		}
+		players = []*mpris.Player{p}
+	}
+
+	refreshed := make([]*tracked, 0, len(players))
+	for _, p := range players {
+		tp, err := t.track(p)
+		if err == nil {
+			var meta *Metadata
+			if meta, err = Parse(tp.player); err == nil {
+				tp.sync(meta, time.Now())
+			}
+		}
+		if err != nil {
+			if !config.Combine {
+				return err
+			}
+			// other players are still shown
+			slog.Debug("Failed to track player", "player", p.GetName(), "error", err)
+			continue
+		}
+		refreshed = append(refreshed, tp)
+	}
+
+	if len(refreshed) == 0 {
+		return ErrNoPlayer
+	}
+
+	t.players = refreshed
+	t.synced = time.Now()
+	return nil
+}
+
+// track returns the tracked player of p, or a new one if p is not tracked.
+func (t *Tracker) track(p *mpris.Player) (*tracked, error) {
+	for _, tp := range t.players {
+		if tp.player.GetName() == p.GetName() {
+			return tp, nil
+		}
+	}
+
+	owner, err := nameOwner(t.conn, p.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &tracked{player: p, owner: owner, meta: nil, synced: time.Time{}, whole: 0}, nil
+}
+
+// coarseSamples is the number of consecutive whole second positions to
+// consider a player's position coarse.
+const coarseSamples = 3
+
+// sync sets the metadata parsed at time now. If config.InterpolatePosition is
+// set, players which only report position in whole seconds are detected, and
+// their extrapolated position is kept while it is within the reported second,
+// so the position does not jump back on every sync.
+func (p *tracked) sync(meta *Metadata, now time.Time) {
+	prev := p.meta
+	var estimated time.Duration
+	if prev != nil {
+		estimated = p.extrapolate(now).Position
+	}
+	p.meta, p.synced = meta, now
+
+	if !config.InterpolatePosition || prev == nil || prev.ID != meta.ID {
+		p.whole = 0
+		return
+	}
+
+	reported := meta.Position - positionOffset(p.player.GetName(), meta.URL)
+	if reported%time.Second == 0 {
+		p.whole++
+	} else {
+		p.whole = 0
+	}
+
+	if p.whole < coarseSamples || prev.Status != mpris.PlaybackPlaying || meta.Status != mpris.PlaybackPlaying {
+		return
+	}
+	if estimated >= meta.Position && estimated < meta.Position+time.Second {
+		meta.Position = estimated
+	}
+}
+
+// extrapolate returns a copy of metadata with the position at time now.
+func (p *tracked) extrapolate(now time.Time) *Metadata {
+	meta := *p.meta
+	if meta.Status == mpris.PlaybackPlaying {
+		meta.Position += meta.Elapsed(now.Sub(p.synced))
+	}
+	if meta.Length > 0 {
+		meta.Position = min(meta.Position, meta.Length)
+	}
+	return &meta
+}
@@ -0,0 +1,123 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/go-mpris"
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/godbus/dbus/v5"
+)
+
+func TestTrackerExtrapolate(t *testing.T) {
+	synced := time.Now()
+
+	tests := []struct {
+		name     string
+		status   mpris.PlaybackStatus
+		rate     float64
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{"playing", mpris.PlaybackPlaying, 1, 2 * time.Second, 12 * time.Second},
+		{"double rate", mpris.PlaybackPlaying, 2, 2 * time.Second, 14 * time.Second},
+		{"slow rate", mpris.PlaybackPlaying, 0.5, 2 * time.Second, 11 * time.Second},
+		{"unknown rate", mpris.PlaybackPlaying, 0, 2 * time.Second, 12 * time.Second},
+		{"paused", mpris.PlaybackPaused, 1, 2 * time.Second, 10 * time.Second},
+		{"past length", mpris.PlaybackPlaying, 1, time.Minute, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := new(tracked)
+			p.meta = &Metadata{Status: tt.status, Length: 30 * time.Second, Rate: tt.rate}
+			p.seek(10*time.Second, synced)
+
+			got := p.extrapolate(synced.Add(tt.elapsed))
+			if got.Position != tt.expected {
+				t.Errorf("extrapolate(%v) = %v; want %v", tt.elapsed, got.Position, tt.expected)
+			}
+			if p.meta.Position != 10*time.Second {
+				t.Errorf("extrapolate modified cached position: %v", p.meta.Position)
+			}
+		})
+	}
+}
+
+func TestTrackerInterpolate(t *testing.T) {
+	config.InterpolatePosition = true
+	t.Cleanup(func() { config.InterpolatePosition = false })
+
+	start := time.Now()
+	p := &tracked{player: new(mpris.Player)}
+
+	// the player reports the position of 10.7s after start in whole seconds
+	tests := []struct {
+		at       time.Duration
+		reported time.Duration
+		expected time.Duration
+	}{
+		{0, 10 * time.Second, 10 * time.Second},
+		{2100 * time.Millisecond, 12 * time.Second, 12 * time.Second},
+		{4500 * time.Millisecond, 15 * time.Second, 15 * time.Second},
+		{7 * time.Second, 17 * time.Second, 17500 * time.Millisecond},
+		{9400 * time.Millisecond, 20 * time.Second, 20 * time.Second},
+		{11250 * time.Millisecond, 21 * time.Second, 21850 * time.Millisecond},
+		// a fractional position means the player is precise
+		{12 * time.Second, 22700 * time.Millisecond, 22700 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		p.sync(&Metadata{ID: "id", Status: mpris.PlaybackPlaying, Position: tt.reported}, start.Add(tt.at))
+		if p.meta.Position != tt.expected {
+			t.Errorf("sync(%v) at %v = %v; want %v", tt.reported, tt.at, p.meta.Position, tt.expected)
+		}
+	}
+}
+
+func TestTrackerHandle(t *testing.T) {
+	tests := []struct {
+		name     string
+		signal   *dbus.Signal
+		expected bool
+	}{
+		{
+			"player properties",
+			&dbus.Signal{Name: mpris.PropertiesChangedSignal, Body: []any{mpris.PlayerInterface}},
+			true,
+		},
+		{
+			"base properties",
+			&dbus.Signal{Name: mpris.PropertiesChangedSignal, Body: []any{mpris.BaseInterface}},
+			false,
+		},
+		{
+			"player appeared",
+			&dbus.Signal{Name: nameOwnerChangedSignal, Body: []any{mpris.BaseInterface + ".spotify"}},
+			true,
+		},
+		{
+			"other name",
+			&dbus.Signal{Name: nameOwnerChangedSignal, Body: []any{"org.freedesktop.Notifications"}},
+			false,
+		},
+		{
+			"seeked without player",
+			&dbus.Signal{Name: seekedSignal, Body: []any{int64(0)}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracker(nil)
+			tr.dirty = false
+			if got := tr.Handle(tt.signal); got != tt.expected {
+				t.Errorf("Handle(%s) = %v; want %v", tt.signal.Name, got, tt.expected)
+			}
+			if tr.dirty != tt.expected {
+				t.Errorf("Handle(%s) dirty = %v; want %v", tt.signal.Name, tr.dirty, tt.expected)
+			}
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"hash/fnv"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/Nadim147c/go-mpris"
+	"github.com/Nadim147c/waybar-lyric/internal/config"
 )
 
 const (
@@ -20,7 +22,7 @@ func computeID(p *mpris.Player, m *Metadata) string {
 	var buf bytes.Buffer
 
 	playerName := p.GetName()
-	buf.WriteString(stripPlayerName(playerName))
+	buf.WriteString(StripName(playerName))
 	buf.WriteByte('-')
 
 	urlStr := removeUnwantedURLParameters(m.URL)
@@ -82,16 +84,40 @@ func isNonZero[T comparable](v T) bool {
 	return zero != v
 }
 
-func hasAlbumAndArtists(p *mpris.Player) bool {
-	album, err := p.GetAlbum()
-	if err != nil || album == "" {
+// metadataGetter is the metadata getters of *mpris.Player.
+type metadataGetter interface {
+	GetTitle() (string, error)
+	GetAlbum() (string, error)
+	GetArtist() ([]string, error)
+	GetLength() (time.Duration, error)
+}
+
+// hasRequiredMetadata reports whether the player has title and the metadata
+// required by config.RequireMeta.
+func hasRequiredMetadata(p metadataGetter) bool {
+	title, err := p.GetTitle()
+	if err != nil || title == "" {
 		return false
 	}
-	artists, err := p.GetArtist()
-	if err != nil || len(artists) == 0 {
-		return false
+	if config.Requires(config.MetadataAlbum) {
+		album, err := p.GetAlbum()
+		if err != nil || album == "" {
+			return false
+		}
+	}
+	if config.Requires(config.MetadataArtist) {
+		artists, err := p.GetArtist()
+		if err != nil || !slices.ContainsFunc(artists, isNonZero) {
+			return false
+		}
+	}
+	if config.Requires(config.MetadataLength) {
+		length, err := p.GetLength()
+		if err != nil || length <= 0 {
+			return false
+		}
 	}
-	return slices.ContainsFunc(artists, isNonZero)
+	return true
 }
 
 func removeUnwantedURLParameters(u *URL) string {
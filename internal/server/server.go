@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/state"
+)
+
+//go:embed index.html
+var index []byte
+
+const (
+	// EventTrack is sent when the track or its lyrics changes.
+	EventTrack = "track"
+	// EventLine is sent when current line changes.
+	EventLine = "line"
+	// EventWord is sent when current word changes.
+	EventWord = "word"
+	// EventWaybar is sent when waybar output changes.
+	EventWaybar = "waybar"
+)
+
+// keepAliveInterval is the interval of comments sent to idle event streams.
+const keepAliveInterval = 15 * time.Second
+
+// subscriberBuffer is the number of events buffered for each subscriber.
+// Events are dropped for subscribers that can not keep up.
+const subscriberBuffer = 64
+
+type event struct {
+	name string
+	data []byte
+}
+
+type trackEvent struct {
+	Metadata *player.Metadata `json:"metadata"`
+	Provider string           `json:"provider"`
+	Score    float64          `json:"score"`
+	Lines    models.Lines     `json:"lines"`
+}
+
+type lineEvent struct {
+	Index int          `json:"index"`
+	Line  *models.Line `json:"line"`
+	Next  *models.Line `json:"next"`
+}
+
+type wordEvent struct {
+	Index    int           `json:"index"`
+	Word     int           `json:"word"`
+	Position time.Duration `json:"position"`
+}
+
+// snapshot is the response of the state endpoint.
+type snapshot struct {
+	state.State
+	Line *models.Line `json:"line"`
+	Next *models.Line `json:"next"`
+}
+
+// Server serves the current lyrics over HTTP. Server implements
+// state.Publisher.
+type Server struct {
+	mu          sync.Mutex
+	last        state.State
+	subscribers map[chan event]struct{}
+}
+
+// New creates a new Server.
+func New() *Server {
+	s := new(Server)
+	s.last = state.Empty(nil)
+	s.subscribers = make(map[chan event]struct{})
+	return s
+}
+
+// Publish sends events for everything that changed since last state.
+func (s *Server) Publish(st state.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.last
+	s.last = st
+
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	trackChanged := !st.SameLyrics(last)
+	if trackChanged {
+		s.broadcast(EventTrack, newTrackEvent(st))
+	}
+	if trackChanged || st.Index != last.Index {
+		s.broadcast(EventLine, newLineEvent(st))
+	}
+	if trackChanged || st.Index != last.Index || st.Word != last.Word {
+		s.broadcast(EventWord, newWordEvent(st))
+	}
+	if st.Waybar != nil && !st.Waybar.Is(last.Waybar) {
+		s.broadcast(EventWaybar, st.Waybar)
+	}
+}
+
+// broadcast sends the event to all subscribers. The caller must hold the lock.
+func (s *Server) broadcast(name string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Failed to marshal server event", "event", name, "error", err)
+		return
+	}
+
+	ev := event{name, data}
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			slog.Debug("Dropping event for slow subscriber", "event", name)
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns events of the current state.
+func (s *Server) subscribe() (chan event, []event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan event, subscriberBuffer)
+	s.subscribers[ch] = struct{}{}
+
+	type value struct {
+		name string
+		v    any
+	}
+	values := []value{
+		{EventTrack, newTrackEvent(s.last)},
+		{EventLine, newLineEvent(s.last)},
+		{EventWord, newWordEvent(s.last)},
+	}
+	if s.last.Waybar != nil {
+		values = append(values, value{EventWaybar, s.last.Waybar})
+	}
+
+	initial := make([]event, 0, len(values))
+	for _, v := range values {
+		data, err := json.Marshal(v.v)
+		if err != nil {
+			continue
+		}
+		initial = append(initial, event{v.name, data})
+	}
+
+	return ch, initial
+}
+
+func (s *Server) unsubscribe(ch chan event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, ch)
+}
+
+func newTrackEvent(st state.State) trackEvent {
+	return trackEvent{
+		Metadata: st.Metadata,
+		Provider: st.Provider,
+		Score:    st.Score,
+		Lines:    st.Lines,
+	}
+}
+
+func newLineEvent(st state.State) lineEvent {
+	return lineEvent{Index: st.Index, Line: linePtr(st.Line()), Next: linePtr(st.Next())}
+}
+
+func newWordEvent(st state.State) wordEvent {
+	var pos time.Duration
+	if st.Metadata != nil {
+		pos = st.Metadata.Position
+	}
+	return wordEvent{Index: st.Index, Word: st.Word, Position: pos}
+}
+
+func linePtr(line models.Line, ok bool) *models.Line {
+	if !ok {
+		return nil
+	}
+	return &line
+}
+
+// Handler returns the http.Handler of the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /api/state", s.handleState)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(index) //nolint:errcheck
+}
+
+func (s *Server) handleState(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	st := s.last
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(snapshot{
+		State: st,
+		Line:  linePtr(st.Line()),
+		Next:  linePtr(st.Next()),
+	})
+	if err != nil {
+		slog.Error("Failed to write state", "error", err)
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch, initial := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	for _, ev := range initial {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case ev := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+		}
+		flusher.Flush()
+	}
+}
+
+// ListenAndServe serves the server on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// event streams end when ctx is done
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx) //nolint:errcheck,contextcheck
+	}()
+
+	slog.Info("Serving lyrics", "url", "http://"+ln.Addr().String())
+
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// compile time check.
+var _ state.Publisher = (*Server)(nil)
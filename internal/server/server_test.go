@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/state"
+)
+
+func testLyrics() models.Lyrics {
+	return models.Lyrics{
+		Metadata: &player.Metadata{ID: "id", Title: "title", Position: 2 * time.Second},
+		Lines: models.Lines{
+			{Timestamp: 0, Text: "first"},
+			{Timestamp: time.Second, Text: "second <&>"},
+			{Timestamp: 5 * time.Second, Text: "third"},
+		},
+		Provider: "test",
+	}
+}
+
+func TestHandleState(t *testing.T) {
+	s := New()
+	s.Publish(state.New(testLyrics(), 1))
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/state status = %d; want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "second <&>") {
+		t.Errorf("GET /api/state escaped HTML: %s", rec.Body.String())
+	}
+
+	var got struct {
+		Provider string       `json:"provider"`
+		Index    int          `json:"index"`
+		Line     *models.Line `json:"line"`
+		Next     *models.Line `json:"next"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Provider != "test" || got.Index != 1 || got.Line == nil || got.Line.Text != "second <&>" ||
+		got.Next == nil || got.Next.Text != "third" {
+		t.Errorf("GET /api/state = %+v; want line 1 of test provider", got)
+	}
+}
+
+// readEvents sends the events of the stream to the returned channel.
+func readEvents(t *testing.T, body *bufio.Scanner) <-chan [2]string {
+	t.Helper()
+
+	events := make(chan [2]string)
+	go func() {
+		defer close(events)
+		var name string
+		for body.Scan() {
+			line := body.Text()
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				events <- [2]string{name, v}
+			}
+		}
+	}()
+	return events
+}
+
+func TestHandleEvents(t *testing.T) {
+	lyrics := testLyrics()
+	s := New()
+	s.Publish(state.New(lyrics, 0))
+
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q; want text/event-stream", ct)
+	}
+
+	events := readEvents(t, bufio.NewScanner(resp.Body))
+	next := func() [2]string {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+			return [2]string{}
+		}
+	}
+
+	for _, name := range []string{EventTrack, EventLine, EventWord} {
+		if ev := next(); ev[0] != name {
+			t.Fatalf("initial event = %q; want %q", ev[0], name)
+		}
+	}
+
+	s.Publish(state.New(lyrics, 1))
+
+	ev := next()
+	if ev[0] != EventLine {
+		t.Fatalf("event = %q; want %q", ev[0], EventLine)
+	}
+	var line lineEvent
+	if err := json.Unmarshal([]byte(ev[1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Index != 1 || line.Line == nil || line.Line.Text != "second <&>" {
+		t.Errorf("line event = %s; want line 1", ev[1])
+	}
+
+	if ev := next(); ev[0] != EventWord {
+		t.Fatalf("event = %q; want %q", ev[0], EventWord)
+	}
+
+	// upgraded lyrics of the same track
+	upgraded := testLyrics()
+	upgraded.Provider = "upgraded"
+	s.Publish(state.New(upgraded, 1))
+
+	if ev := next(); ev[0] != EventTrack || !strings.Contains(ev[1], `"upgraded"`) {
+		t.Errorf("event = %q %s; want %q with upgraded lyrics", ev[0], ev[1], EventTrack)
+	}
+}
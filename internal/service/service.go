@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Nadim147c/waybar-lyric/internal/state"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	// BusName is the well-known D-Bus name of the service.
+	BusName = "org.waybar_lyric"
+	// ObjectPath is the D-Bus object path of the service.
+	ObjectPath dbus.ObjectPath = "/org/waybar_lyric"
+	// Interface is the D-Bus interface of the lyrics properties.
+	Interface = "org.waybar_lyric.Lyrics"
+)
+
+// ErrNameTaken when another process owns the bus name.
+var ErrNameTaken = errors.New("bus name is already taken")
+
+// Line is a lyrics line of the Lines property with D-Bus signature (xs).
+type Line struct {
+	// Timestamp is the start of the line in microseconds, like MPRIS Position.
+	Timestamp int64
+	Text      string
+}
+
+// Service exports the current lyrics state as D-Bus properties. Every change
+// emits org.freedesktop.DBus.Properties.PropertiesChanged.
+type Service struct {
+	mu    sync.Mutex
+	props *prop.Properties
+	last  state.State
+	lines []Line
+}
+
+// Export exports the service on the connection and requests BusName.
+func Export(conn *dbus.Conn) (*Service, error) {
+	//nolint:exhaustruct
+	props, err := prop.Export(conn, ObjectPath, prop.Map{
+		Interface: {
+			"TrackID":   {Value: "", Emit: prop.EmitTrue},
+			"Line":      {Value: "", Emit: prop.EmitTrue},
+			"LineIndex": {Value: int32(-1), Emit: prop.EmitTrue},
+			"WordIndex": {Value: int32(-1), Emit: prop.EmitTrue},
+			"NextLine":  {Value: "", Emit: prop.EmitTrue},
+			"Lines":     {Value: []Line{}, Emit: prop.EmitTrue},
+			"Provider":  {Value: "", Emit: prop.EmitTrue},
+			"Score":     {Value: float64(0), Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export properties: %w", err)
+	}
+
+	node := &introspect.Node{
+		Name: string(ObjectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: Interface, Properties: props.Introspection(Interface)}, //nolint:exhaustruct
+		},
+	}
+	err = conn.Export(introspect.NewIntrospectable(node), ObjectPath, introspect.IntrospectData.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export introspection: %w", err)
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request bus name: %w", err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, ErrNameTaken
+	}
+
+	s := &Service{props: props, last: state.Empty(nil), lines: []Line{}}
+	return s, nil
+}
+
+// Publish updates the properties when current line, word or track changes.
+func (s *Service) Publish(st state.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trackChanged := !st.SameLyrics(s.last)
+	if !trackChanged && st.Index == s.last.Index && st.Word == s.last.Word {
+		return
+	}
+
+	if trackChanged {
+		lines := make([]Line, len(st.Lines))
+		for i, l := range st.Lines {
+			lines[i] = Line{Timestamp: l.Timestamp.Microseconds(), Text: l.Text}
+		}
+
+		s.set("TrackID", st.ID())
+		s.set("Provider", st.Provider)
+		s.set("Score", st.Score)
+		if !slices.Equal(lines, s.lines) {
+			s.set("Lines", lines)
+			s.lines = lines
+		}
+	}
+
+	line, _ := st.Line()
+	next, _ := st.Next()
+	prevLine, _ := s.last.Line()
+	prevNext, _ := s.last.Next()
+
+	if trackChanged || line.Text != prevLine.Text {
+		s.set("Line", line.Text)
+	}
+	if trackChanged || next.Text != prevNext.Text {
+		s.set("NextLine", next.Text)
+	}
+	if trackChanged || st.Index != s.last.Index {
+		s.set("LineIndex", int32(st.Index)) //nolint:gosec
+	}
+	if trackChanged || st.Word != s.last.Word {
+		s.set("WordIndex", int32(st.Word)) //nolint:gosec
+	}
+
+	s.last = st
+}
+
+func (s *Service) set(name string, value any) {
+	s.props.SetMust(Interface, name, value)
+}
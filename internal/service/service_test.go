@@ -0,0 +1,90 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/dbustest"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/state"
+	"github.com/godbus/dbus/v5"
+)
+
+func TestPublish(t *testing.T) {
+	address := dbustest.Bus(t)
+
+	s, err := Export(dbustest.Connect(t, address))
+	if err != nil {
+		t.Fatalf("Export() = %v", err)
+	}
+
+	lyrics := models.Lyrics{
+		Metadata: &player.Metadata{ID: "track"},
+		Lines: models.Lines{
+			{Timestamp: 0, Text: "first"},
+			{Timestamp: 1500 * time.Millisecond, Text: "second"},
+		},
+		Provider: "test",
+		Score:    0.5,
+	}
+	s.Publish(state.New(lyrics, 1))
+
+	obj := dbustest.Connect(t, address).Object(BusName, ObjectPath)
+	get := func(name string) dbus.Variant {
+		t.Helper()
+		v, err := obj.GetProperty(Interface + "." + name)
+		if err != nil {
+			t.Fatalf("GetProperty(%q) = %v", name, err)
+		}
+		return v
+	}
+
+	if sig := get("Lines").Signature().String(); sig != "a(xs)" {
+		t.Errorf("Lines signature = %q; want %q", sig, "a(xs)")
+	}
+
+	var lines []Line
+	if err := get("Lines").Store(&lines); err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{{Timestamp: 0, Text: "first"}, {Timestamp: 1_500_000, Text: "second"}}
+	if !slices.Equal(lines, want) {
+		t.Errorf("Lines = %v; want %v", lines, want)
+	}
+
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"TrackID", "track"},
+		{"Line", "second"},
+		{"NextLine", ""},
+		{"LineIndex", int32(1)},
+		{"WordIndex", int32(-1)},
+		{"Provider", "test"},
+		{"Score", 0.5},
+	}
+	for _, tt := range tests {
+		if got := get(tt.name).Value(); got != tt.want {
+			t.Errorf("%s = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// upgraded lyrics of the same track
+	upgraded := lyrics
+	upgraded.Lines = models.Lines{
+		{Timestamp: 0, Text: "first"},
+		{Timestamp: time.Second, Text: "second"},
+	}
+	upgraded.Provider, upgraded.Score = "upgraded", 1.5
+	s.Publish(state.New(upgraded, 1))
+
+	if got := get("Provider").Value(); got != "upgraded" {
+		t.Errorf("Provider = %v after upgrade; want %q", got, "upgraded")
+	}
+	if err := get("Lines").Store(&lines); err != nil || lines[1].Timestamp != 1_000_000 {
+		t.Errorf("Lines = %v, %v after upgrade; want upgraded lines", lines, err)
+	}
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/waybar"
+)
+
+// State is a snapshot of the lyrics computed by the main loop.
+type State struct {
+	Metadata *player.Metadata `json:"metadata"`
+	Lines    models.Lines     `json:"lines"`
+	Provider string           `json:"provider"`
+	Score    float64          `json:"score"`
+	// Index is the index of current line, -1 when there are no lyrics.
+	Index int `json:"index"`
+	// Word is the index of current word in current line, -1 when current line
+	// is not word synced or no word has started yet.
+	Word int `json:"word"`
+	// Waybar is the output displayed for this state.
+	Waybar *waybar.Waybar `json:"waybar"`
+}
+
+// Publisher receives the State every time the main loop computes it.
+type Publisher interface {
+	Publish(s State)
+}
+
+// New creates a State for the lyrics at given line index.
+func New(lyrics models.Lyrics, idx int) State {
+	s := State{
+		Metadata: lyrics.Metadata,
+		Lines:    lyrics.Lines,
+		Provider: lyrics.Provider,
+		Score:    lyrics.Score,
+		Index:    idx,
+		Word:     -1,
+		Waybar:   nil,
+	}
+	if idx >= 0 && idx < len(lyrics.Lines) && lyrics.Metadata != nil {
+		s.Word = CurrentWord(lyrics.Lines[idx], lyrics.Metadata.Position)
+	}
+	return s
+}
+
+// Empty creates a State without lyrics.
+func Empty(metadata *player.Metadata) State {
+	return State{Metadata: metadata, Index: -1, Word: -1} //nolint:exhaustruct
+}
+
+// CurrentWord returns index of the last word that started before pos, or -1.
+func CurrentWord(line models.Line, pos time.Duration) int {
+	idx := -1
+	for i, w := range line.Words {
+		if w.IsSeparator() {
+			continue
+		}
+		if pos < w.Start {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// Line returns the current line.
+func (s State) Line() (models.Line, bool) {
+	return s.line(s.Index)
+}
+
+// Next returns the line after current line.
+func (s State) Next() (models.Line, bool) {
+	if s.Index < 0 {
+		return models.Line{}, false
+	}
+	return s.line(s.Index + 1)
+}
+
+func (s State) line(i int) (models.Line, bool) {
+	if i < 0 || i >= len(s.Lines) {
+		return models.Line{}, false
+	}
+	return s.Lines[i], true
+}
+
+// ID returns the track id of the State.
+func (s State) ID() string {
+	if s.Metadata == nil {
+		return ""
+	}
+	return s.Metadata.ID
+}
+
+// SameLyrics reports whether both states have the same lyrics of the same
+// track. Lyrics which are upgraded or processed again with new options keep the
+// track id, but have different provider, score or lines.
+func (s State) SameLyrics(other State) bool {
+	return s.ID() == other.ID() &&
+		s.Provider == other.Provider &&
+		s.Score == other.Score &&
+		sameLines(s.Lines, other.Lines)
+}
+
+// sameLines reports whether a and b are the same slice. Lines are shared by
+// every state of the lyrics until the lyrics are replaced.
+func sameLines(a, b models.Lines) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// NextBoundary returns the position of the next line or word change after the
+// current position.
+func (s State) NextBoundary() (time.Duration, bool) {
+	if s.Metadata == nil || s.Index < 0 {
+		return 0, false
+	}
+	pos := s.Metadata.Position
+
+	next, ok := time.Duration(0), false
+	if line, found := s.Line(); found {
+		for _, w := range line.Words {
+			if !w.IsSeparator() && w.Start > pos {
+				next, ok = w.Start, true
+				break
+			}
+		}
+	}
+	for _, line := range s.Lines[s.Index:] {
+		if line.Timestamp > pos {
+			if !ok || line.Timestamp < next {
+				next, ok = line.Timestamp, true
+			}
+			break
+		}
+	}
+	return next, ok
+}
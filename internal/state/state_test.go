@@ -0,0 +1,91 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+)
+
+func TestNextBoundary(t *testing.T) {
+	lines := models.Lines{
+		{Timestamp: time.Second, Text: "one"},
+		{
+			Timestamp: 3 * time.Second,
+			Text:      "two words",
+			Words: []models.Word{
+				{Start: 3 * time.Second, End: 4 * time.Second, Text: "two"},
+				{Start: -1, End: -1, Text: " "},
+				{Start: 4500 * time.Millisecond, End: 5 * time.Second, Text: "words"},
+			},
+		},
+		{Timestamp: 6 * time.Second, Text: "three"},
+	}
+
+	tests := []struct {
+		position time.Duration
+		index    int
+		expected time.Duration
+		ok       bool
+	}{
+		{0, 0, time.Second, true},
+		{2 * time.Second, 0, 3 * time.Second, true},
+		{3500 * time.Millisecond, 1, 4500 * time.Millisecond, true},
+		{5 * time.Second, 1, 6 * time.Second, true},
+		{7 * time.Second, 2, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position.String(), func(t *testing.T) {
+			s := Empty(&player.Metadata{Position: tt.position})
+			s.Lines = lines
+			s.Index = tt.index
+
+			next, ok := s.NextBoundary()
+			if next != tt.expected || ok != tt.ok {
+				t.Errorf("NextBoundary() at %v = %v, %v; want %v, %v", tt.position, next, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSameLyrics(t *testing.T) {
+	meta := &player.Metadata{ID: "track"}
+	lines := models.Lines{{Timestamp: time.Second, Text: "line"}}
+	lyrics := models.Lyrics{Metadata: meta, Lines: lines, Provider: "lrclib", Score: 1}
+
+	upgraded := lyrics
+	upgraded.Lines = models.Lines{{
+		Timestamp: time.Second,
+		Text:      "line",
+		Words:     []models.Word{{Start: time.Second, End: 2 * time.Second, Text: "line"}},
+	}}
+	upgraded.Provider, upgraded.Score = "youlyplus", 2
+
+	reprocessed := lyrics
+	reprocessed.Lines = models.Lines{{Timestamp: time.Second, Text: "LINE"}}
+
+	other := lyrics
+	other.Metadata = &player.Metadata{ID: "other"}
+
+	tests := []struct {
+		name     string
+		other    State
+		expected bool
+	}{
+		{"next line", New(lyrics, 0), true},
+		{"upgraded", New(upgraded, 0), false},
+		{"processed again", New(reprocessed, 0), false},
+		{"other track", New(other, 0), false},
+		{"no lyrics", Empty(meta), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(lyrics, 0).SameLyrics(tt.other); got != tt.expected {
+				t.Errorf("SameLyrics() = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package str
+
+import (
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+// ScrollOffset returns the column offset of the visible window of a text with
+// given display width, elapsed time since the text is displayed. The text is
+// scrolled at config.ScrollSpeed with config.ScrollPause at start and end. If
+// available is positive, the scroll is sped up to finish within available
+// duration.
+func ScrollOffset(width int, elapsed, available time.Duration) int {
+	overflow := width - config.MaxTextLength
+	if overflow <= 0 || elapsed <= 0 || config.ScrollSpeed <= 0 {
+		return 0
+	}
+
+	pause := config.ScrollPause
+	duration := time.Duration(float64(overflow) / config.ScrollSpeed * float64(time.Second))
+	if available > 0 {
+		pause = min(pause, available/4)
+		duration = min(duration, available-2*pause)
+	}
+
+	elapsed -= pause
+	if elapsed <= 0 {
+		return 0
+	}
+	if elapsed >= duration {
+		return overflow
+	}
+	return int(float64(overflow) * float64(elapsed) / float64(duration))
+}
+
+// Scroll returns visible window of the input after elapsed time. See
+// ScrollOffset.
+func Scroll(input string, elapsed, available time.Duration) string {
+	width := Width(input)
+	if width <= config.MaxTextLength {
+		return input
+	}
+	offset := ScrollOffset(width, elapsed, available)
+	return Slice(input, offset, offset+config.MaxTextLength)
+}
@@ -0,0 +1,57 @@
+package str
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+func TestScroll(t *testing.T) {
+	limit, speed, pause := config.MaxTextLength, config.ScrollSpeed, config.ScrollPause
+	t.Cleanup(func() {
+		config.MaxTextLength, config.ScrollSpeed, config.ScrollPause = limit, speed, pause
+	})
+
+	config.MaxTextLength = 5
+	config.ScrollSpeed = 1
+	config.ScrollPause = time.Second
+
+	tests := []struct {
+		name      string
+		input     string
+		elapsed   time.Duration
+		available time.Duration
+		expected  string
+	}{
+		{"short", "abc", 10 * time.Second, 0, "abc"},
+		{"start", "abcdefgh", 0, 0, "abcde"},
+		{"pause", "abcdefgh", time.Second, 0, "abcde"},
+		{"scrolling", "abcdefgh", 3 * time.Second, 0, "cdefg"},
+		{"end", "abcdefgh", 4 * time.Second, 0, "defgh"},
+		{"after end", "abcdefgh", time.Minute, 0, "defgh"},
+		// 3 characters in 1s, after 0.5s pause
+		{"fast", "abcdefgh", 1500 * time.Millisecond, 2 * time.Second, "defgh"},
+		{"fast middle", "abcdefgh", time.Second, 2 * time.Second, "bcdef"},
+		{"unicode", "àéîõüçñß", 2 * time.Second, 0, "éîõüç"},
+		{"wide", "こんにちは", 0, 0, "こん"},
+		{"wide scrolling", "こんにちは", 2 * time.Second, 0, "んに"},
+		{"wide half", "こんにちは", 4 * time.Second, 0, "にち"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := Scroll(tt.input, tt.elapsed, tt.available)
+			if output != tt.expected {
+				t.Errorf(
+					"Scroll(%q, %v, %v) = %q; want %q",
+					tt.input,
+					tt.elapsed,
+					tt.available,
+					output,
+					tt.expected,
+				)
+			}
+		})
+	}
+}
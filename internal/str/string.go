@@ -1,50 +1,112 @@
 package str
 
 import (
-	"slices"
 	"strings"
 
 	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/rivo/uniseg"
 )
 
-// BreakLine breaks a line at word boundaries if it exceeds the limit.
+// BreakLine breaks a line at spaces if its display width exceeds the limit.
+// Words wider than the limit are broken at line break opportunities, so text
+// without spaces (e.g. CJK) is broken between characters.
 func BreakLine(line string, limit int) string {
-	if len(line) <= limit {
+	if uniseg.StringWidth(line) <= limit {
 		return line
 	}
 
-	words := strings.Fields(line)
 	var out strings.Builder
 
-	var lineLen int
-	for word := range slices.Values(words) {
-		wordlen := len(word)
-		if lineLen == 0 {
-			out.WriteString(word)
-			lineLen += wordlen
-		} else if lineLen+wordlen < limit {
-			out.WriteByte(' ') // add space
-			out.WriteString(word)
-			lineLen += wordlen + 1
-		} else {
-			out.WriteByte('\n') // add line break
-			out.WriteString(word)
-			lineLen = wordlen
+	var lineWidth int
+	for _, word := range strings.Fields(line) {
+		wordWidth := uniseg.StringWidth(word)
+		if lineWidth > 0 && wordWidth <= limit && lineWidth+1+wordWidth > limit {
+			out.WriteByte('\n') // move the word to next line
+			lineWidth = 0
+		}
+
+		space := lineWidth > 0
+		state := -1
+		for word != "" {
+			segment := word
+			if wordWidth > limit {
+				segment, word, _, state = uniseg.FirstLineSegmentInString(word, state)
+			} else {
+				word = ""
+			}
+
+			width := uniseg.StringWidth(segment)
+			var sep int
+			if space {
+				sep = 1
+			}
+			if lineWidth > 0 && lineWidth+sep+width > limit {
+				out.WriteByte('\n') // add line break
+				lineWidth = 0
+			} else if space {
+				out.WriteByte(' ') // add space
+				lineWidth++
+			}
+			space = false
+
+			out.WriteString(segment)
+			lineWidth += width
 		}
 	}
 
 	return out.String()
 }
 
-// Truncate truncates using rune length from user input.
+// Truncate truncates the input to the display width from user input. Grapheme
+// clusters are never split.
 func Truncate(input string) string {
 	limit := config.MaxTextLength
-	r := []rune(input)
-	if len(r) <= limit {
+	if uniseg.StringWidth(input) <= limit {
 		return input
 	}
-	if limit > 3 {
-		return string(r[:limit-3]) + "..."
+
+	ellipsis := "..."
+	if limit <= len(ellipsis) {
+		ellipsis = ""
+	}
+	limit -= len(ellipsis)
+
+	var out strings.Builder
+	var width int
+	state := -1
+	for input != "" {
+		var cluster string
+		var w int
+		cluster, input, w, state = uniseg.FirstGraphemeClusterInString(input, state)
+		if width+w > limit {
+			break
+		}
+		out.WriteString(cluster)
+		width += w
+	}
+
+	return strings.TrimRight(out.String(), " ") + ellipsis
+}
+
+// Width returns the display width of the input.
+func Width(input string) int {
+	return uniseg.StringWidth(input)
+}
+
+// Slice returns the grapheme clusters of the input which are displayed within
+// the columns from (inclusive) and to (exclusive).
+func Slice(input string, from, to int) string {
+	var out strings.Builder
+	var col int
+	state := -1
+	for input != "" && col < to {
+		var cluster string
+		var w int
+		cluster, input, w, state = uniseg.FirstGraphemeClusterInString(input, state)
+		if col >= from && col+w <= to {
+			out.WriteString(cluster)
+		}
+		col += w
 	}
-	return string(r[:limit])
+	return out.String()
 }
@@ -0,0 +1,81 @@
+package str
+
+import (
+	"testing"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int
+		expected string
+	}{
+		{"hello world", 20, "hello world"},
+		{"hello world", 8, "hello..."},
+		{"hello world", 3, "hel"},
+		{"こんにちは世界", 14, "こんにちは世界"},
+		{"こんにちは世界", 10, "こんに..."},
+		{"こんにちは世界", 9, "こんに..."},
+		{"안녕하세요 world", 10, "안녕하..."},
+		{"love 사랑 love", 10, "love 사..."},
+		{"café ñandú", 8, "café..."},
+		{"café nandu", 7, "café..."},
+		{"👍🏽👍🏽👍🏽👍🏽", 7, "👍🏽👍🏽..."},
+		{"👨‍👩‍👧 family", 6, "👨‍👩‍👧..."},
+	}
+
+	limit := config.MaxTextLength
+	t.Cleanup(func() { config.MaxTextLength = limit })
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			config.MaxTextLength = tt.limit
+			output := Truncate(tt.input)
+			if output != tt.expected {
+				t.Errorf(
+					"Truncate(%q) with limit %d = %q; want %q",
+					tt.input,
+					tt.limit,
+					output,
+					tt.expected,
+				)
+			}
+		})
+	}
+}
+
+func TestBreakLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int
+		expected string
+	}{
+		{"hello world", 20, "hello world"},
+		{"hello world foo bar", 11, "hello world\nfoo bar"},
+		{"hello   world", 7, "hello\nworld"},
+		{"supercalifragilistic is long", 10, "supercalifragilistic\nis long"},
+		{"こんにちは世界", 6, "こんに\nちは世\n界"},
+		{"私は日本語を話します", 10, "私は日本語\nを話します"},
+		{"나는 한국어를 합니다", 10, "나는\n한국어를\n합니다"},
+		{"I love 東京タワー so much", 12, "I love\n東京タワー\nso much"},
+		{"歌詞 こんにちは世界", 8, "歌詞 こ\nんにちは\n世界"},
+		{"👍🏽👍🏽👍🏽 yes", 6, "👍🏽👍🏽👍🏽\nyes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			output := BreakLine(tt.input, tt.limit)
+			if output != tt.expected {
+				t.Errorf(
+					"BreakLine(%q, %d) = %q; want %q",
+					tt.input,
+					tt.limit,
+					output,
+					tt.expected,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package transliterate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillic is the romanization of Russian, Ukrainian, Belarusian and Serbian
+// Cyrillic letters.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "w",
+	'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+}
+
+func isCyrillic(r rune) bool {
+	_, ok := cyrillic[unicode.ToLower(r)]
+	return ok
+}
+
+// romanizeCyrillic romanizes Cyrillic letters. Case of the letters is kept.
+func romanizeCyrillic(letters []rune) string {
+	var out strings.Builder
+
+	for i, r := range letters {
+		lower := unicode.ToLower(r)
+		latin := cyrillic[lower]
+		if r == lower || latin == "" {
+			out.WriteString(latin)
+			continue
+		}
+
+		// all caps words stay all caps (e.g. ЖУК -> ZHUK)
+		nextUpper := i+1 < len(letters) && unicode.IsUpper(letters[i+1])
+		prevUpper := i > 0 && unicode.IsUpper(letters[i-1])
+		if nextUpper || prevUpper {
+			out.WriteString(strings.ToUpper(latin))
+		} else {
+			out.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+		}
+	}
+
+	return out.String()
+}
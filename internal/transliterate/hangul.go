@@ -0,0 +1,84 @@
+package transliterate
+
+import "strings"
+
+const (
+	hangulFirst = 0xAC00
+	hangulLast  = 0xD7A3
+
+	medialCount = 21
+	finalCount  = 28
+
+	initialRieul = 5  // ㄹ
+	initialIeung = 11 // ㅇ
+	finalRieul   = 8  // ㄹ
+)
+
+// initials are the romanization of initial consonants.
+var initials = [...]string{
+	"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s",
+	"ss", "", "j", "jj", "ch", "k", "t", "p", "h",
+}
+
+// medials are the romanization of vowels.
+var medials = [...]string{
+	"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae",
+	"oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i",
+}
+
+// finals are the romanization of final consonants followed by a consonant or
+// at the end of a word.
+var finals = [...]string{
+	"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l",
+	"p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t",
+}
+
+// linkedFinals are the romanization of final consonants followed by silent
+// initial ㅇ, where the final is pronounced as the next initial.
+var linkedFinals = [...]string{
+	"", "g", "kk", "ks", "n", "nj", "n", "d", "r", "lg", "lm", "lb", "ls", "lt",
+	"lp", "r", "m", "b", "bs", "s", "ss", "ng", "j", "ch", "k", "t", "p", "",
+}
+
+func isHangul(r rune) bool {
+	return r >= hangulFirst && r <= hangulLast
+}
+
+// decompose returns the initial, medial and final indexes of the syllable.
+func decompose(r rune) (int, int, int) {
+	i := int(r - hangulFirst)
+	return i / (medialCount * finalCount), i % (medialCount * finalCount) / finalCount, i % finalCount
+}
+
+// romanizeHangul romanizes consecutive Hangul syllables using Revised
+// Romanization of Korean. Only the consonant linking rules are applied.
+func romanizeHangul(syllables []rune) string {
+	var out strings.Builder
+
+	prevFinal := 0
+	for i, r := range syllables {
+		initial, medial, final := decompose(r)
+
+		if prevFinal == finalRieul && initial == initialRieul {
+			out.WriteString("l")
+		} else {
+			out.WriteString(initials[initial])
+		}
+		out.WriteString(medials[medial])
+
+		linked := false
+		if i+1 < len(syllables) {
+			next, _, _ := decompose(syllables[i+1])
+			linked = next == initialIeung
+		}
+		if linked {
+			out.WriteString(linkedFinals[final])
+		} else {
+			out.WriteString(finals[final])
+		}
+
+		prevFinal = final
+	}
+
+	return out.String()
+}
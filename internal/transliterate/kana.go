@@ -0,0 +1,137 @@
+package transliterate
+
+import "strings"
+
+const (
+	hiraganaFirst = 0x3041
+	hiraganaLast  = 0x3096
+	katakanaFirst = 0x30A1
+	katakanaLast  = 0x30F6
+
+	// katakanaOffset is the distance between katakana and hiragana.
+	katakanaOffset = katakanaFirst - hiraganaFirst
+
+	smallTsu      = 'っ'
+	prolongedMark = 'ー'
+)
+
+// kana is the Hepburn romanization of hiragana.
+var kana = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "wi", 'ゑ': "we", 'を': "wo", 'ん': "n",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa", 'ゔ': "vu",
+	'ゕ': "ka", 'ゖ': "ke",
+}
+
+// smallY are the small kana combined with previous i-row kana (e.g. きゃ).
+var smallY = map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}
+
+// smallVowels are the small vowels combined with previous kana (e.g. ファ).
+var smallVowels = map[rune]string{'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o"}
+
+func isKana(r rune) bool {
+	return r >= hiraganaFirst && r <= hiraganaLast ||
+		r >= katakanaFirst && r <= katakanaLast ||
+		r == prolongedMark
+}
+
+// toHiragana converts katakana to hiragana.
+func toHiragana(r rune) rune {
+	if r >= katakanaFirst && r <= katakanaLast {
+		return r - katakanaOffset
+	}
+	return r
+}
+
+// romanizeKana romanizes consecutive hiragana and katakana using Hepburn
+// romanization.
+func romanizeKana(runes []rune) string {
+	var out strings.Builder
+
+	geminate := false
+	for i := 0; i < len(runes); i++ {
+		r := toHiragana(runes[i])
+
+		switch r {
+		case smallTsu:
+			geminate = true
+			continue
+		case prolongedMark:
+			// repeat the last vowel
+			if s := out.String(); s != "" && strings.ContainsRune("aiueo", rune(s[len(s)-1])) {
+				out.WriteByte(s[len(s)-1])
+			}
+			continue
+		}
+
+		syllable := kana[r]
+		if i+1 < len(runes) {
+			next := toHiragana(runes[i+1])
+			if combined, ok := combine(syllable, next); ok {
+				syllable = combined
+				i++
+			}
+		}
+
+		if geminate && syllable != "" && !strings.ContainsRune("aiueon", rune(syllable[0])) {
+			if strings.HasPrefix(syllable, "ch") {
+				out.WriteByte('t')
+			} else {
+				out.WriteByte(syllable[0])
+			}
+		}
+		geminate = false
+
+		out.WriteString(syllable)
+	}
+
+	return out.String()
+}
+
+// combine combines syllable with the following small kana.
+func combine(syllable string, next rune) (string, bool) {
+	if vowel, ok := smallY[next]; ok && len(syllable) > 1 && strings.HasSuffix(syllable, "i") {
+		base := syllable[:len(syllable)-1]
+		switch base {
+		case "sh", "ch", "j":
+			return base + vowel, true
+		default:
+			return base + "y" + vowel, true
+		}
+	}
+
+	if vowel, ok := smallVowels[next]; ok {
+		switch syllable {
+		case "fu", "vu":
+			return syllable[:1] + vowel, true
+		case "te", "de":
+			if vowel == "i" || vowel == "u" {
+				return syllable[:1] + vowel, true
+			}
+		case "shi", "chi", "ji":
+			if vowel == "e" {
+				return syllable[:len(syllable)-1] + vowel, true
+			}
+		case "u":
+			if vowel != "u" {
+				return "w" + vowel, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,69 @@
+// Package transliterate romanizes Korean Hangul, Cyrillic and Japanese kana
+// using rule-based transliteration. Text in other scripts is left unchanged.
+package transliterate
+
+import "strings"
+
+// script is a writing system supported by Romanize.
+type script int
+
+const (
+	scriptOther script = iota
+	scriptHangul
+	scriptKana
+	scriptCyrillic
+)
+
+func scriptOf(r rune) script {
+	switch {
+	case isHangul(r):
+		return scriptHangul
+	case isKana(r):
+		return scriptKana
+	case isCyrillic(r):
+		return scriptCyrillic
+	default:
+		return scriptOther
+	}
+}
+
+// Romanize returns the input with Hangul, Cyrillic and kana transliterated to
+// Latin script.
+func Romanize(input string) string {
+	runes := []rune(input)
+
+	var out strings.Builder
+	out.Grow(len(input))
+
+	for start := 0; start < len(runes); {
+		kind := scriptOf(runes[start])
+		end := start + 1
+		for end < len(runes) && scriptOf(runes[end]) == kind {
+			end++
+		}
+
+		run := runes[start:end]
+		switch kind {
+		case scriptHangul:
+			out.WriteString(romanizeHangul(run))
+		case scriptKana:
+			out.WriteString(romanizeKana(run))
+		case scriptCyrillic:
+			out.WriteString(romanizeCyrillic(run))
+		default:
+			out.WriteString(string(run))
+		}
+
+		start = end
+	}
+
+	return out.String()
+}
+
+// Romanizable reports whether the input contains any text that Romanize
+// transliterates.
+func Romanizable(input string) bool {
+	return strings.ContainsFunc(input, func(r rune) bool {
+		return scriptOf(r) != scriptOther
+	})
+}
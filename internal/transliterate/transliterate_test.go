@@ -0,0 +1,70 @@
+package transliterate
+
+import "testing"
+
+func TestRomanize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Hangul
+		{"사랑해", "saranghae"},
+		{"한국어", "hangugeo"},
+		{"안녕하세요", "annyeonghaseyo"},
+		{"서울", "seoul"},
+		{"몰라", "molla"},
+		{"좋아", "joa"},
+		{"읽어", "ilgeo"},
+		{"밥 먹었어?", "bap meogeosseo?"},
+		// Cyrillic
+		{"Привет, мир", "Privet, mir"},
+		{"щука", "shchuka"},
+		{"ЖУК", "ZHUK"},
+		{"Європа", "Yevropa"},
+		// kana
+		{"こんにちは", "konnichiha"},
+		{"ありがとう", "arigatou"},
+		{"きょう", "kyou"},
+		{"しゃしん", "shashin"},
+		{"ちょっと", "chotto"},
+		{"まっちゃ", "matcha"},
+		{"カラオケ", "karaoke"},
+		{"コーヒー", "koohii"},
+		{"ファン", "fan"},
+		{"パーティー", "paatii"},
+		// mixed
+		{"君の名は。", "君no名ha。"},
+		{"love 사랑 любовь", "love sarang lyubov"},
+		{"hello", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			output := Romanize(tt.input)
+			if output != tt.expected {
+				t.Errorf("Romanize(%q) = %q; want %q", tt.input, output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRomanizable(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"hello", false},
+		{"君", false},
+		{"君の", true},
+		{"사랑", true},
+		{"мир", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if output := Romanizable(tt.input); output != tt.expected {
+				t.Errorf("Romanizable(%q) = %v; want %v", tt.input, output, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package waybar
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+	"github.com/Nadim147c/waybar-lyric/internal/str"
+)
+
+// Markup is text which is already Pango markup, and is not escaped in format
+// templates.
+type Markup string
+
+// Data is the data available in format templates. All fields of
+// player.Metadata are available (e.g. {{.Artist}}, {{.Title}}, {{.Album}}).
+// The output of every action is escaped for Pango markup except Markup values.
+type Data struct {
+	*player.Metadata
+	// Line is the current line. Word synced lines contains markup for sung
+	// words.
+	Line Markup
+	// Text is the current line without markup.
+	Text string
+	// Translation is the translation of the current line.
+	Translation string
+	// Romanization is the romanization of the current line.
+	Romanization string
+	// Previous is the previous line.
+	Previous string
+	// Next is the next line.
+	Next string
+	// Sung is the text of current line that has been sung.
+	Sung string
+	// Unsung is the text of current line that has not been sung yet.
+	Unsung string
+	// WordIndex is the index of the current word, -1 if not word synced.
+	WordIndex int
+	// WordCount is the number of words in current line.
+	WordCount int
+	// Position is the formatted player position (e.g. 1:05).
+	Position string
+	// Length is the formatted track length (e.g. 3:42).
+	Length string
+	// Provider is the name of the lyrics provider.
+	Provider string
+	// Tooltip is the default tooltip.
+	Tooltip Markup
+}
+
+// escapeFunc is the name of the function appended to every action.
+const escapeFunc = "_escape"
+
+var funcs = template.FuncMap{
+	"truncate": str.Truncate,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"trim":     strings.TrimSpace,
+	escapeFunc: escapeValue,
+}
+
+// escapeValue escapes the output of an action for Pango markup.
+func escapeValue(v any) string {
+	if m, ok := v.(Markup); ok {
+		return string(m)
+	}
+	return escape(fmt.Sprint(v))
+}
+
+// escapeActions appends escapeFunc to the pipeline of every action which
+// prints a value.
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) != 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(escapeFunc).SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+var (
+	templatesMu sync.Mutex
+	templates   = map[string]*template.Template{}
+)
+
+func parseFormat(text string) (*template.Template, error) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	if t, ok := templates[text]; ok {
+		return t, nil
+	}
+
+	t, err := template.New("format").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, tmpl := range t.Templates() {
+		escapeActions(tmpl.Root)
+	}
+	templates[text] = t
+	return t, nil
+}
+
+// CheckFormats parses all format templates. It returns the first error.
+// Templates of previous formats are removed. Execution errors depend on the
+// player and are reported by render.
+func CheckFormats() error {
+	templatesMu.Lock()
+	clear(templates)
+	templatesMu.Unlock()
+
+	formats := map[string]string{
+		"format":          config.Format,
+		"format-paused":   config.FormatPaused,
+		"format-no-lyric": config.FormatNoLyric,
+		"tooltip-format":  config.TooltipFormat,
+	}
+	for name, text := range formats {
+		if text == "" {
+			continue
+		}
+		if _, err := parseFormat(text); err != nil {
+			return fmt.Errorf("invalid %s template: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// render executes the format template with data. It returns false if the
+// format is empty or invalid. Parse errors are reported by CheckFormats.
+func render(format string, data Data) (string, bool) {
+	if format == "" {
+		return "", false
+	}
+
+	t, err := parseFormat(format)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		slog.Error("Failed to execute format template", "format", format, "error", err)
+		return "", false
+	}
+	return b.String(), true
+}
+
+func newData(m *player.Metadata) Data {
+	return Data{
+		Metadata:  m,
+		WordIndex: -1,
+		Position:  formatDuration(m.Position),
+		Length:    formatDuration(m.Length),
+	}
+}
+
+func newLyricsData(lyrics models.Lyrics, idx int) Data {
+	data := newData(lyrics.Metadata)
+	data.Provider = lyrics.Provider
+
+	lines := lyrics.Lines
+	current := lines[idx]
+	data.Text = current.Text
+	data.Translation = current.Translation
+	data.Romanization = current.Romanization
+	if idx > 0 {
+		data.Previous = lines[idx-1].Text
+	}
+	if idx+1 < len(lines) {
+		data.Next = lines[idx+1].Text
+	}
+
+	if len(current.Words) == 0 {
+		data.Sung = current.Text
+		return data
+	}
+
+	var sung, unsung strings.Builder
+	pos := lyrics.Metadata.Position
+	for i, w := range current.Words {
+		if !w.IsSeparator() {
+			data.WordCount++
+		}
+		if pos < w.Start || (w.IsSeparator() && unsung.Len() != 0) {
+			unsung.WriteString(w.Text)
+			continue
+		}
+		sung.WriteString(w.Text)
+		if !w.IsSeparator() {
+			data.WordIndex = i
+		}
+	}
+	data.Sung = sung.String()
+	data.Unsung = unsung.String()
+
+	return data
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", d/time.Minute, (d%time.Minute)/time.Second)
+}
@@ -0,0 +1,80 @@
+package waybar
+
+import (
+	"testing"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+)
+
+func TestRender(t *testing.T) {
+	data := newData(testMetadata())
+	data.Line = Markup("<b>line</b>")
+	data.Provider = "a & b"
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+		ok       bool
+	}{
+		{"empty format falls back", "", "", false},
+		{"plain text", "lyrics", "lyrics", true},
+		{"escaped field", "{{.Provider}}", "a &amp; b", true},
+		{"markup field", "{{.Line}}", "<b>line</b>", true},
+		{"attribute", `<span foreground="{{.Provider}}">x</span>`, `<span foreground="a &amp; b">x</span>`, true},
+		{"function result", "{{upper .Provider}}", "A &amp; B", true},
+		{"conditional", "{{if .Provider}}[{{.Provider}}]{{end}}", "[a &amp; b]", true},
+		{"variable", "{{$p := .Provider}}{{$p}}", "a &amp; b", true},
+		{"invalid", "{{.Provider", "", false},
+		{"execution error", "{{index .Provider 10}}", "", false},
+		{"nil field", "{{.URL.Host}}", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := render(tt.format, data)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("render(%q) = %q, %v; want %q, %v", tt.format, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"valid", "{{.Artist}} - {{.Title}} {{.Line}}", false},
+		{"parse error", "{{.Title", true},
+		{"unknown function", "{{nope .Title}}", true},
+		{"nil field", "{{.URL.Host}} {{.Title}}", false},
+		{"index", "{{index .Artists 0}}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFormats(t, tt.format, "")
+			if err := CheckFormats(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFormats() with %q = %v; want error %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFormatsRemovesOldTemplates(t *testing.T) {
+	for _, format := range []string{"{{.Title}} 1", "{{.Title}} 2", "{{.Title}} 3"} {
+		setFormats(t, format, "")
+		if err := CheckFormats(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	if _, ok := templates[config.Format]; !ok || len(templates) != 1 {
+		t.Errorf("templates = %d formats; want only the current format", len(templates))
+	}
+}
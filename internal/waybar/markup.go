@@ -0,0 +1,102 @@
+package waybar
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+)
+
+// escape escapes text to be used in Pango markup. All user provided strings
+// (lyrics, metadata, options) must be escaped before writing them to Text or
+// Tooltip.
+func escape(s string) string {
+	return html.EscapeString(s)
+}
+
+// reTag matches a Pango markup tag. Text is always escaped, so any < starts a
+// tag.
+var reTag = regexp.MustCompile(`<[^>]*>`)
+
+// stripMarkup returns the text of Pango markup without tags and entities.
+func stripMarkup(markup string) string {
+	return html.UnescapeString(reTag.ReplaceAllString(markup, ""))
+}
+
+// bold wraps the markup in bold tag.
+func bold(markup string) string {
+	return "<b>" + markup + "</b>"
+}
+
+// foreground wraps the markup in span with foreground color.
+func foreground(color, markup string) string {
+	return `<span foreground="` + escape(color) + `">` + markup + "</span>"
+}
+
+// sung returns markup for text that has been sung.
+func sung(text string) string {
+	markup := bold(escape(text))
+	if config.SungColor != "" {
+		markup = foreground(config.SungColor, markup)
+	}
+	return markup
+}
+
+// unsung returns markup for text that has not been sung yet.
+func unsung(text string) string {
+	markup := escape(text)
+	if config.UnsungColor != "" {
+		markup = foreground(config.UnsungColor, markup)
+	}
+	return markup
+}
+
+// karaoke returns markup for word synced line at pos. The runes of the word
+// being sung are split into sung and unsung part by its progress.
+func karaoke(words []models.Word, pos time.Duration) string {
+	var out, buf strings.Builder
+	var bufSung bool
+
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		if bufSung {
+			out.WriteString(sung(buf.String()))
+		} else {
+			out.WriteString(unsung(buf.String()))
+		}
+		buf.Reset()
+	}
+	write := func(text string, isSung bool) {
+		if isSung != bufSung {
+			flush()
+			bufSung = isSung
+		}
+		buf.WriteString(text)
+	}
+
+	for _, w := range words {
+		switch {
+		case w.IsSeparator():
+			// separators follow the previous word
+			buf.WriteString(w.Text)
+		case pos < w.Start:
+			write(w.Text, false)
+		case pos >= w.End || w.End <= w.Start:
+			write(w.Text, true)
+		default:
+			runes := []rune(w.Text)
+			progress := float64(pos-w.Start) / float64(w.End-w.Start)
+			n := int(float64(len(runes)) * progress)
+			write(string(runes[:n]), true)
+			write(string(runes[n:]), false)
+		}
+	}
+	flush()
+
+	return out.String()
+}
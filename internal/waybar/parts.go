@@ -0,0 +1,149 @@
+package waybar
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/str"
+)
+
+// partSeparator separates the parts of a line in text.
+const partSeparator = " / "
+
+// part is a displayable part (original, translation or romanization) of a
+// line.
+type part struct {
+	text  string
+	words []models.Word
+}
+
+// lineParts returns the non-empty parts of the line selected by config.Show.
+// If none of them exists, the original line is returned.
+func lineParts(line models.Line) []part {
+	parts := make([]part, 0, len(config.Show))
+	for _, show := range config.Show {
+		var p part
+		switch show {
+		case config.ShowOriginal:
+			p = part{line.Text, line.Words}
+		case config.ShowTranslation:
+			p = part{line.Translation, nil}
+		case config.ShowRomanization:
+			p = part{line.Romanization, line.RomanizedWords}
+		}
+		if p.text != "" || len(p.words) != 0 {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) == 0 {
+		return []part{{line.Text, line.Words}}
+	}
+	return parts
+}
+
+// display returns the text and words of the line to display. Words is nil if
+// none of the shown parts is word synced.
+func display(line models.Line) (string, []models.Word) {
+	parts := lineParts(line)
+	if len(parts) == 1 {
+		return parts[0].text, parts[0].words
+	}
+
+	texts := make([]string, 0, len(parts))
+	var words []models.Word
+	var synced bool
+	for i, p := range parts {
+		texts = append(texts, p.text)
+		if i != 0 {
+			words = append(words, separator(partSeparator))
+		}
+		if len(p.words) != 0 {
+			words = append(words, p.words...)
+			synced = true
+		} else {
+			words = append(words, unsyncedWord(p.text))
+		}
+	}
+
+	if !synced {
+		words = nil
+	}
+	return strings.Join(texts, partSeparator), words
+}
+
+// tooltipMarkup returns markup of the shown parts of line for tooltip
+// separated by newlines. Each part is broken by config.BreakTooltip.
+// Background vocals are put in a smaller line below the part and lines sung
+// by an agent other than primary are italic.
+func tooltipMarkup(line models.Line, primary string) string {
+	parts := lineParts(line)
+	lines := make([]string, 0, len(parts))
+	for _, p := range parts {
+		main, background := splitBackground(p)
+		lines = append(lines, escape(str.BreakLine(main, config.BreakTooltip)))
+		if background != "" {
+			background = escape(str.BreakLine(background, config.BreakTooltip))
+			lines = append(lines, "<small>"+background+"</small>")
+		}
+	}
+
+	markup := strings.Join(lines, "\n")
+	if markup != "" && line.Agent != "" && line.Agent != primary {
+		markup = "<i>" + markup + "</i>"
+	}
+	return markup
+}
+
+// splitBackground returns the main and background vocals of the part.
+func splitBackground(p part) (string, string) {
+	if !slices.ContainsFunc(p.words, func(w models.Word) bool { return w.Background }) {
+		return p.text, ""
+	}
+
+	var main, background strings.Builder
+	for _, w := range p.words {
+		if w.Background {
+			background.WriteString(w.Text)
+		} else {
+			main.WriteString(w.Text)
+		}
+	}
+	return strings.TrimSpace(main.String()), strings.TrimSpace(background.String())
+}
+
+// primaryAgent returns the agent of the first line with an agent.
+func primaryAgent(lines models.Lines) string {
+	for _, line := range lines {
+		if line.Agent != "" {
+			return line.Agent
+		}
+	}
+	return ""
+}
+
+// isBackground reports whether the word being sung at pos is a background
+// vocal.
+func isBackground(words []models.Word, pos time.Duration) bool {
+	var background bool
+	for _, w := range words {
+		if w.IsSeparator() || pos < w.Start {
+			continue
+		}
+		background = w.Background && pos < w.End
+	}
+	return background
+}
+
+func separator(text string) models.Word {
+	return models.Word{Start: -1, End: -1, Text: text}
+}
+
+// unsyncedWord returns a word which is never sung.
+func unsyncedWord(text string) models.Word {
+	return models.Word{Start: math.MaxInt64, End: math.MaxInt64, Text: text}
+}
@@ -3,10 +3,12 @@ package waybar
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/Nadim147c/waybar-lyric/internal/config"
 	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
@@ -21,9 +23,19 @@ func ForPlayer(p *player.Metadata) *Waybar {
 		alt = Paused
 	}
 
+	data := newData(p)
+
 	var text string
 	if !config.LyricOnly {
-		text = fmt.Sprintf("%s - %s", p.Artist, p.Title)
+		text = escape(fmt.Sprintf("%s - %s", p.Artist, p.Title))
+		if t, ok := render(config.FormatNoLyric, data); ok {
+			text = t
+		}
+	}
+
+	var tooltip string
+	if !config.NoTooltip {
+		tooltip, _ = render(config.TooltipFormat, data)
 	}
 
 	waybar := &Waybar{
@@ -32,7 +44,9 @@ func ForPlayer(p *player.Metadata) *Waybar {
 		Class:      Class{alt},
 		Text:       text,
 		Alt:        alt,
+		Tooltip:    tooltip,
 		Percentage: p.Percentage(),
+		data:       data,
 	}
 
 	if config.Detailed {
@@ -54,12 +68,14 @@ func ForLyrics(lyrics models.Lyrics, idx int) *Waybar {
 	var tooltip strings.Builder
 
 	if !config.NoTooltip {
-		fmt.Fprintf(&tooltip, "<span foreground=\"%s\">", config.TooltipColor)
+		color := escape(config.TooltipColor)
+		fmt.Fprintf(&tooltip, "<span foreground=\"%s\">", color)
 
+		primary := primaryAgent(lines)
 		lastIndex := len(lyricsContext) - 1
 		for i, ttl := range lyricsContext {
-			line := str.BreakLine(ttl.Text, config.BreakTooltip)
-			if ttl.Text == "" {
+			line := tooltipMarkup(ttl, primary)
+			if line == "" {
 				line = "󰝚 "
 			}
 
@@ -67,7 +83,7 @@ func ForLyrics(lyrics models.Lyrics, idx int) *Waybar {
 				newLine := fmt.Sprintf(
 					"</span><b><big>%s</big></b>\n<span foreground=\"%s\">",
 					line,
-					config.TooltipColor,
+					color,
 				)
 				tooltip.WriteString(newLine)
 				continue
@@ -82,25 +98,47 @@ func ForLyrics(lyrics models.Lyrics, idx int) *Waybar {
 		tooltip.WriteString("</span>")
 	}
 
+	pos := lyrics.Metadata.Position
+	elapsed := pos - currentLine.Timestamp
+	var available time.Duration
+	if idx+1 < len(lines) {
+		available = lines[idx+1].Timestamp - currentLine.Timestamp
+	}
+
+	text, words := display(currentLine)
+
 	var line string
-	if len(currentLine.Words) > 0 {
-		var b strings.Builder
-		pos := lyrics.Metadata.Position
-		for _, w := range currentLine.Words {
-			if pos < w.Start {
-				b.WriteString(w.Text)
-			} else {
-				b.WriteString("<b>")
-				b.WriteString(w.Text)
-				b.WriteString("</b>")
-			}
-		}
-		line = b.String()
-	} else {
-		line = str.Truncate(currentLine.Text)
+	switch {
+	case len(words) > 0 && config.Scroll:
+		line = karaoke(scrollWords(words, elapsed, available), pos)
+	case len(words) > 0:
+		line = karaoke(words, pos)
+	case config.Scroll:
+		line = escape(str.Scroll(text, elapsed, available))
+	default:
+		line = escape(str.Truncate(text))
+	}
+
+	data := newLyricsData(lyrics, idx)
+	data.Line = Markup(line) // escaped above
+	data.Tooltip = Markup(tooltip.String())
+
+	if t, ok := render(config.Format, data); ok {
+		line = t
+	}
+
+	if t, ok := render(config.TooltipFormat, data); ok && !config.NoTooltip {
+		tooltip.Reset()
+		tooltip.WriteString(t)
 	}
 
 	class := Class{Lyric, Playing}
+	if currentLine.Agent != "" {
+		class = append(class, Status("agent-"+currentLine.Agent))
+	}
+	if isBackground(currentLine.Words, pos) {
+		class = append(class, Background)
+	}
 	waybar := &Waybar{
 		ID:      lyrics.Metadata.ID,
 		Player:  lyrics.Metadata.Player,
@@ -108,6 +146,7 @@ func ForLyrics(lyrics models.Lyrics, idx int) *Waybar {
 		Class:   class,
 		Text:    line,
 		Tooltip: tooltip.String(),
+		data:    data,
 	}
 
 	if config.Detailed {
@@ -121,6 +160,71 @@ func ForLyrics(lyrics models.Lyrics, idx int) *Waybar {
 	return waybar
 }
 
+// statusNotes is the note shown in the tooltip after the name of players
+// without lyrics.
+var statusNotes = map[Status]string{
+	Getting: "getting lyrics",
+	NoLyric: "no lyrics",
+}
+
+// Combine returns the Waybar of the first shown player with the text of other
+// shown players in the tooltip. Players which are not shown are Zero.
+func Combine(players []*Waybar) *Waybar {
+	shown := slices.DeleteFunc(slices.Clone(players), func(w *Waybar) bool { return w == Zero })
+	if len(shown) == 0 {
+		return Zero
+	}
+
+	combined := *shown[0]
+	if config.NoTooltip || len(shown) == 1 {
+		return &combined
+	}
+
+	var tooltip strings.Builder
+	tooltip.WriteString(combined.Tooltip)
+	for _, other := range shown[1:] {
+		if tooltip.Len() != 0 {
+			tooltip.WriteString("\n\n")
+		}
+		fmt.Fprintf(&tooltip, "<b>%s</b>", escape(player.StripName(other.Player)))
+		if note, ok := statusNotes[other.Alt]; ok {
+			fmt.Fprintf(&tooltip, " <i>(%s)</i>", note)
+		}
+		fmt.Fprintf(&tooltip, "\n%s", other.Text)
+	}
+	combined.Tooltip = tooltip.String()
+
+	return &combined
+}
+
+// scrollWords returns the part of words visible in the scroll window. See
+// str.ScrollOffset.
+func scrollWords(words []models.Word, elapsed, available time.Duration) []models.Word {
+	var width int
+	for _, w := range words {
+		width += str.Width(w.Text)
+	}
+	if width <= config.MaxTextLength {
+		return words
+	}
+
+	start := str.ScrollOffset(width, elapsed, available)
+	end := start + config.MaxTextLength
+
+	visible := make([]models.Word, 0, len(words))
+	var offset int
+	for _, w := range words {
+		text := str.Slice(w.Text, start-offset, end-offset)
+		offset += str.Width(w.Text)
+		if text == "" {
+			continue
+		}
+		w.Text = text
+		visible = append(visible, w)
+	}
+	return visible
+}
+
 // Zero is a empty Waybar.
 var Zero = &Waybar{}
 
@@ -134,6 +238,9 @@ const (
 	Paused  Status = "paused"
 	NoLyric Status = "no_lyric"
 	Getting Status = "getting"
+
+	// Background is the class when a background vocal is being sung.
+	Background Status = "background"
 )
 
 // Class is waybar class which can be either a string slice or string.
@@ -150,18 +257,33 @@ type Waybar struct {
 	Percentage int              `json:"percentage"`
 	Info       *player.Metadata `json:"info,omitempty"`
 	Lines      models.Lines     `json:"lines,omitempty"`
+
+	// data is the template data used to create the Waybar.
+	data Data
 }
 
+// Output is the writer where Waybar is encoded to. Each encoded Waybar is
+// written with a single Write call.
+var Output io.Writer = os.Stdout
+
 // JSON is the json encoder for waybar.
-var JSON = json.NewEncoder(os.Stdout)
+var JSON = newEncoder(Output)
+
+func newEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
 
-func init() {
-	JSON.SetEscapeHTML(false)
+// SetOutput sets the writer where Waybar is encoded to.
+func SetOutput(w io.Writer) {
+	Output = w
+	JSON = newEncoder(w)
 }
 
-// SetText sets truncates the given txt and sets to text value.
+// SetText sets truncates and escapes the given txt and sets to text value.
 func (w *Waybar) SetText(txt string) {
-	w.Text = str.Truncate(txt)
+	w.Text = escape(str.Truncate(txt))
 }
 
 var lastLine string
@@ -169,20 +291,21 @@ var lastLine string
 // Encode prints the Waybar as json to Stdout.
 func (w *Waybar) Encode() {
 	if config.LyricOnly && (w.Alt == Paused || w.Alt == Music) {
-		fmt.Println("") //nolint:forbidigo
+		fmt.Fprintln(Output, "")
 		return
 	}
 
 	if config.Compact {
-		if lastLine != w.Text {
-			fmt.Println(w.Text) //nolint:forbidigo
-			lastLine = w.Text
+		text := stripMarkup(w.Text)
+		if lastLine != text {
+			fmt.Fprintln(Output, text)
+			lastLine = text
 		}
 		return
 	}
 
 	if w == Zero {
-		fmt.Println("{}") //nolint:forbidigo
+		fmt.Fprintln(Output, "{}")
 		return
 	}
 
@@ -234,6 +357,9 @@ func (w *Waybar) Is(other *Waybar) bool {
 	if w.Info.Volume != other.Info.Volume {
 		return false
 	}
+	if w.Info.Rate != other.Info.Rate {
+		return false
+	}
 
 	return true
 }
@@ -241,8 +367,27 @@ func (w *Waybar) Is(other *Waybar) bool {
 // Paused set Text to artist and title on default mode.
 func (w *Waybar) Paused(info *player.Metadata) {
 	if !config.LyricOnly {
-		w.Text = fmt.Sprintf("%s - %s", info.Artist, info.Title)
+		w.Text = escape(fmt.Sprintf("%s - %s", info.Artist, info.Title))
+		if t, ok := render(config.FormatPaused, w.templateData(info)); ok {
+			w.Text = t
+		}
 	}
 	w.Alt = Paused
 	w.Class = Class{Paused}
 }
+
+// Music sets Text to artist and title when current line is empty.
+func (w *Waybar) Music(info *player.Metadata) {
+	w.SetText(fmt.Sprintf("%s - %s", info.Artist, info.Title))
+	if t, ok := render(config.FormatNoLyric, w.templateData(info)); ok {
+		w.Text = t
+	}
+	w.Alt = Music
+}
+
+func (w *Waybar) templateData(info *player.Metadata) Data {
+	if w.data.Metadata == nil {
+		return newData(info)
+	}
+	return w.data
+}
@@ -0,0 +1,344 @@
+package waybar
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/waybar-lyric/internal/config"
+	"github.com/Nadim147c/waybar-lyric/internal/lyric/models"
+	"github.com/Nadim147c/waybar-lyric/internal/player"
+)
+
+const hostile = `Tom & Jerry <b>"Rock'n'Roll"</b> > 1`
+
+// plainText parses the Pango markup and returns its text content. It fails
+// the test if the markup is not well-formed.
+func plainText(t *testing.T, markup string) string {
+	t.Helper()
+
+	dec := xml.NewDecoder(strings.NewReader("<markup>" + markup + "</markup>"))
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return b.String()
+		}
+		if err != nil {
+			t.Fatalf("invalid markup %q: %v", markup, err)
+		}
+		if data, ok := tok.(xml.CharData); ok {
+			b.Write(data)
+		}
+	}
+}
+
+func testMetadata() *player.Metadata {
+	return &player.Metadata{
+		Player:   "spotify",
+		ID:       "id",
+		Artist:   hostile,
+		Title:    hostile,
+		Album:    hostile,
+		Position: 2 * time.Second,
+		Length:   time.Minute,
+		Status:   "Playing",
+	}
+}
+
+func testLyrics(words bool) models.Lyrics {
+	lines := models.Lines{
+		{Timestamp: 0, Text: hostile},
+		{Timestamp: time.Second, Text: hostile},
+		{Timestamp: 5 * time.Second, Text: hostile},
+		{Timestamp: 10 * time.Second, Text: hostile},
+	}
+	if words {
+		lines[1].Words = []models.Word{
+			{Start: time.Second, End: 2 * time.Second, Text: "Tom"},
+			{Start: -1, End: -1, Text: " "},
+			{Start: 2 * time.Second, End: 3 * time.Second, Text: "&"},
+			{Start: -1, End: -1, Text: " "},
+			{Start: 3 * time.Second, End: 4 * time.Second, Text: `<i>"Jerry'</i>`},
+		}
+		lines[1].Text = `Tom & <i>"Jerry'</i>`
+	}
+	return models.Lyrics{Metadata: testMetadata(), Lines: lines, Provider: "<test>"}
+}
+
+func setFormats(t *testing.T, format, tooltip string) {
+	t.Helper()
+	config.Format, config.TooltipFormat = format, tooltip
+	config.FormatPaused, config.FormatNoLyric = format, format
+	t.Cleanup(func() {
+		config.Format, config.TooltipFormat = "", ""
+		config.FormatPaused, config.FormatNoLyric = "", ""
+	})
+}
+
+func TestForLyricsEscape(t *testing.T) {
+	tests := []struct {
+		name  string
+		words bool
+		text  string
+	}{
+		{"line", false, hostile},
+		{"words", true, `Tom & <i>"Jerry'</i>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := ForLyrics(testLyrics(tt.words), 1)
+
+			if got := plainText(t, w.Text); got != tt.text {
+				t.Errorf("text = %q; want %q", got, tt.text)
+			}
+			if got := plainText(t, w.Tooltip); !strings.Contains(got, hostile) {
+				t.Errorf("tooltip = %q; want to contain %q", got, hostile)
+			}
+		})
+	}
+}
+
+func TestForLyricsTooltipColorEscape(t *testing.T) {
+	color := config.TooltipColor
+	config.TooltipColor = `red" weight="bold`
+	t.Cleanup(func() { config.TooltipColor = color })
+
+	w := ForLyrics(testLyrics(false), 1)
+	plainText(t, w.Tooltip)
+	if strings.Contains(w.Tooltip, `weight="bold"`) {
+		t.Errorf("tooltip color is not escaped: %q", w.Tooltip)
+	}
+}
+
+func TestForPlayerEscape(t *testing.T) {
+	want := hostile + " - " + hostile
+
+	w := ForPlayer(testMetadata())
+	if got := plainText(t, w.Text); got != want {
+		t.Errorf("text = %q; want %q", got, want)
+	}
+
+	w.Paused(testMetadata())
+	if got := plainText(t, w.Text); got != want {
+		t.Errorf("paused text = %q; want %q", got, want)
+	}
+
+	w.Music(testMetadata())
+	if got := plainText(t, w.Text); got != want {
+		t.Errorf("music text = %q; want %q", got, want)
+	}
+}
+
+func TestFormatEscape(t *testing.T) {
+	setFormats(t,
+		`<span weight="bold">{{.Title}}</span> {{.Line}} {{.Provider}}`,
+		`{{.Album}}{{"\n"}}{{.Tooltip}}`,
+	)
+
+	w := ForLyrics(testLyrics(false), 1)
+	want := hostile + " " + hostile + " <test>"
+	if got := plainText(t, w.Text); got != want {
+		t.Errorf("text = %q; want %q", got, want)
+	}
+	if got := plainText(t, w.Tooltip); !strings.HasPrefix(got, hostile+"\n") {
+		t.Errorf("tooltip = %q; want prefix %q", got, hostile+"\n")
+	}
+
+	w = ForPlayer(testMetadata())
+	want = hostile + " "
+	if got := plainText(t, w.Text); !strings.HasPrefix(got, want) {
+		t.Errorf("no lyric text = %q; want prefix %q", got, want)
+	}
+}
+
+func TestKaraoke(t *testing.T) {
+	words := []models.Word{
+		{Start: 0, End: time.Second, Text: "Hello"},
+		{Start: -1, End: -1, Text: " "},
+		{Start: time.Second, End: 3 * time.Second, Text: "wo&ld"},
+	}
+
+	tests := []struct {
+		pos      time.Duration
+		expected string
+	}{
+		{-time.Second, "Hello wo&amp;ld"},
+		{500 * time.Millisecond, "<b>He</b>llo wo&amp;ld"},
+		{time.Second, "<b>Hello </b>wo&amp;ld"},
+		{2 * time.Second, "<b>Hello wo</b>&amp;ld"},
+		{3 * time.Second, "<b>Hello wo&amp;ld</b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pos.String(), func(t *testing.T) {
+			output := karaoke(words, tt.pos)
+			if output != tt.expected {
+				t.Errorf("karaoke(%v) = %q; want %q", tt.pos, output, tt.expected)
+			}
+		})
+	}
+
+	config.SungColor, config.UnsungColor = "#fff", "#888"
+	t.Cleanup(func() { config.SungColor, config.UnsungColor = "", "" })
+
+	expected := `<span foreground="#fff"><b>He</b></span><span foreground="#888">llo wo&amp;ld</span>`
+	if output := karaoke(words, 500*time.Millisecond); output != expected {
+		t.Errorf("karaoke with colors = %q; want %q", output, expected)
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	line := models.Line{
+		Timestamp: time.Second,
+		Text:      "こんにちは",
+		Words: []models.Word{
+			{Start: time.Second, End: 2 * time.Second, Text: "こん"},
+			{Start: 2 * time.Second, End: 3 * time.Second, Text: "にちは"},
+		},
+		Translation:  "Hello",
+		Romanization: "konnichiwa",
+	}
+
+	show := config.Show
+	t.Cleanup(func() { config.Show = show })
+
+	tests := []struct {
+		show     []string
+		expected string
+		synced   bool
+	}{
+		{[]string{config.ShowOriginal}, "こんにちは", true},
+		{[]string{config.ShowTranslation}, "Hello", false},
+		{[]string{config.ShowRomanization}, "konnichiwa", false},
+		{[]string{config.ShowOriginal, config.ShowTranslation}, "こんにちは / Hello", true},
+		{[]string{config.ShowTranslation, config.ShowRomanization}, "Hello / konnichiwa", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.show, ","), func(t *testing.T) {
+			config.Show = tt.show
+			text, words := display(line)
+			if text != tt.expected {
+				t.Errorf("display() text = %q; want %q", text, tt.expected)
+			}
+			if synced := len(words) != 0; synced != tt.synced {
+				t.Errorf("display() synced = %v; want %v", synced, tt.synced)
+			}
+		})
+	}
+
+	config.Show = []string{config.ShowOriginal, config.ShowTranslation}
+	expected := "<b>こん</b>にちは / Hello"
+	_, words := display(line)
+	if output := karaoke(words, 2*time.Second); output != expected {
+		t.Errorf("karaoke = %q; want %q", output, expected)
+	}
+}
+
+func TestForLyricsAgents(t *testing.T) {
+	lyrics := models.Lyrics{
+		Metadata: testMetadata(),
+		Lines: models.Lines{
+			{Timestamp: 0, Text: "Hello (hey)", Agent: "v1", Words: []models.Word{
+				{Start: 0, End: time.Second, Text: "Hello"},
+				{Start: -1, End: -1, Text: " "},
+				{Start: time.Second, End: 3 * time.Second, Text: "(hey)", Background: true},
+			}},
+			{Timestamp: 5 * time.Second, Text: "Goodbye", Agent: "v2"},
+			{Timestamp: 10 * time.Second, Text: "End", Agent: "v1"},
+			{Timestamp: 15 * time.Second, Text: "", Agent: "v1"},
+		},
+	}
+
+	w := ForLyrics(lyrics, 0)
+	expected := Class{Lyric, Playing, "agent-v1", Background}
+	if !slices.Equal(w.Class, expected) {
+		t.Errorf("class = %v; want %v", w.Class, expected)
+	}
+	if !strings.Contains(w.Tooltip, "Hello\n<small>(hey)</small>") {
+		t.Errorf("tooltip does not contain background vocal: %q", w.Tooltip)
+	}
+	if !strings.Contains(w.Tooltip, "<i>Goodbye</i>") {
+		t.Errorf("tooltip does not contain styled agent line: %q", w.Tooltip)
+	}
+	plainText(t, w.Tooltip)
+
+	lyrics.Metadata.Position = 6 * time.Second
+	w = ForLyrics(lyrics, 1)
+	expected = Class{Lyric, Playing, "agent-v2"}
+	if !slices.Equal(w.Class, expected) {
+		t.Errorf("class = %v; want %v", w.Class, expected)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	spotify := ForLyrics(testLyrics(false), 1)
+	spotify.Player = "org.mpris.MediaPlayer2.spotify"
+	firefox := ForPlayer(testMetadata())
+	firefox.Player = "org.mpris.MediaPlayer2.firefox.instance_1_24"
+	vlc := ForPlayer(testMetadata())
+	vlc.Player = "org.mpris.MediaPlayer2.vlc"
+	vlc.Alt = Getting
+
+	tests := []struct {
+		name    string
+		players []*Waybar
+		text    string
+		others  []string
+	}{
+		{"none", []*Waybar{Zero, Zero}, "", nil},
+		{"single", []*Waybar{spotify}, spotify.Text, nil},
+		{"first shown", []*Waybar{Zero, firefox}, firefox.Text, nil},
+		{"others", []*Waybar{spotify, Zero, firefox}, spotify.Text, []string{"firefox\n"}},
+		{"getting", []*Waybar{spotify, vlc}, spotify.Text, []string{"vlc (getting lyrics)\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := Combine(tt.players)
+			if w.Text != tt.text {
+				t.Errorf("Combine() text = %q; want %q", w.Text, tt.text)
+			}
+
+			tooltip := plainText(t, w.Tooltip)
+			for _, name := range tt.others {
+				if !strings.Contains(tooltip, name+hostile+" - "+hostile) {
+					t.Errorf("Combine() tooltip = %q; want to contain %s player", tooltip, name)
+				}
+			}
+		})
+	}
+
+	tooltip := spotify.Tooltip
+	Combine([]*Waybar{spotify, firefox})
+	if spotify.Tooltip != tooltip {
+		t.Errorf("Combine() modified the tooltip of the first player: %q", spotify.Tooltip)
+	}
+}
+
+func TestEncodeCompact(t *testing.T) {
+	var b strings.Builder
+	SetOutput(&b)
+	config.Compact = true
+	t.Cleanup(func() {
+		SetOutput(os.Stdout)
+		config.Compact = false
+		lastLine = ""
+	})
+
+	for _, words := range []bool{false, true} {
+		ForLyrics(testLyrics(words), 1).Encode()
+	}
+
+	want := hostile + "\n" + `Tom & <i>"Jerry'</i>` + "\n"
+	if b.String() != want {
+		t.Errorf("compact output = %q; want %q", b.String(), want)
+	}
+}